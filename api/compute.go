@@ -0,0 +1,172 @@
+// Copyright 2019 Booking.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Compute console token lifetime is one hour, renew it beforehand
+const computeTokenRenewTimeout = time.Minute * 30
+
+// maximum page size allowed by Compute API list endpoints
+const computePageSize = 50
+
+const computeHTTPTimeout = time.Second * 20
+
+// Compute contain credentials for Prisma Cloud Compute console API access
+type Compute struct {
+	api apiCaller
+}
+
+// DefendersInfo stores connected and disconnected defenders count by defender type
+type DefendersInfo struct {
+	Connected    map[string]int
+	Disconnected map[string]int
+}
+
+// VulnerabilityCounts stores vulnerabilities count by severity
+type VulnerabilityCounts struct {
+	Critical int `json:"critical"`
+	High     int `json:"high"`
+	Medium   int `json:"medium"`
+	Low      int `json:"low"`
+}
+
+// VulnerabilityInfo stores vulnerabilities count of scanned hosts, images and running containers
+type VulnerabilityInfo struct {
+	Hosts      VulnerabilityCounts
+	Images     VulnerabilityCounts
+	Containers VulnerabilityCounts
+}
+
+// defender stores fields of single Compute defender entry
+type defender struct {
+	Hostname  string `json:"hostname"`
+	Type      string `json:"type"`
+	Connected bool   `json:"connected"`
+}
+
+// scanResult stores fields of single Compute host or image scan result
+type scanResult struct {
+	ID                        string              `json:"_id"`
+	VulnerabilityDistribution VulnerabilityCounts `json:"vulnerabilityDistribution"`
+}
+
+// container stores fields of single Compute running container entry
+type container struct {
+	ID   string `json:"_id"`
+	Info struct {
+		ImageID string `json:"imageID"`
+	} `json:"info"`
+}
+
+// NewCompute returns new Prisma Cloud Compute client, consoleURL is the one
+// shown in Compute > Manage > System > Utilities, username and password could be Prisma access key and secret
+func NewCompute(username, password, consoleURL string) *Compute {
+	// Compute calls are retried the same way as Prisma ones, but not rate limited
+	// as listing endpoints are paged by small computePageSize
+	return &Compute{api: &retryCaller{api: newComputeClient(username, password, consoleURL),
+		limiter: rate.NewLimiter(rate.Inf, 1), maxRetries: defaultMaxRetries}}
+}
+
+func newComputeClient(username, password, consoleURL string) *tokenClient {
+	return &tokenClient{username: username, password: password, apiURL: consoleURL,
+		httpClient: http.Client{Timeout: computeHTTPTimeout}, loginPath: "/api/v1/authenticate",
+		renewTimeout: computeTokenRenewTimeout, authHeader: "Authorization", authPrefix: "Bearer "}
+}
+
+// GatherDefendersInfo returns defenders count by type and connection state
+// https://pan.dev/prisma-cloud/api/cwpp/get-defenders/
+func (c *Compute) GatherDefendersInfo() (*DefendersInfo, error) {
+	var defenders []defender
+	if err := computeList(c.api, "/api/v1/defenders", &defenders); err != nil {
+		return nil, fmt.Errorf("error requesting defenders information: %w", err)
+	}
+	info := &DefendersInfo{Connected: map[string]int{}, Disconnected: map[string]int{}}
+	for _, d := range defenders {
+		if d.Connected {
+			info.Connected[d.Type]++
+		} else {
+			info.Disconnected[d.Type]++
+		}
+	}
+	return info, nil
+}
+
+// GatherVulnerabilityInfo returns vulnerabilities count by severity for hosts, images and running containers,
+// with every running container counted with vulnerabilities of the image it runs,
+// containers with image not scanned by Compute are not counted
+// https://pan.dev/prisma-cloud/api/cwpp/get-hosts/
+// https://pan.dev/prisma-cloud/api/cwpp/get-images/
+// https://pan.dev/prisma-cloud/api/cwpp/get-containers/
+func (c *Compute) GatherVulnerabilityInfo() (*VulnerabilityInfo, error) {
+	var hosts, images []scanResult
+	var containers []container
+	if err := computeList(c.api, "/api/v1/hosts", &hosts); err != nil {
+		return nil, fmt.Errorf("error requesting hosts vulnerabilities: %w", err)
+	}
+	if err := computeList(c.api, "/api/v1/images", &images); err != nil {
+		return nil, fmt.Errorf("error requesting images vulnerabilities: %w", err)
+	}
+	if err := computeList(c.api, "/api/v1/containers", &containers); err != nil {
+		return nil, fmt.Errorf("error requesting containers information: %w", err)
+	}
+	info := &VulnerabilityInfo{}
+	for _, h := range hosts {
+		info.Hosts = info.Hosts.add(h.VulnerabilityDistribution)
+	}
+	imageVulns := make(map[string]VulnerabilityCounts, len(images))
+	for _, i := range images {
+		info.Images = info.Images.add(i.VulnerabilityDistribution)
+		imageVulns[i.ID] = i.VulnerabilityDistribution
+	}
+	for _, cont := range containers {
+		info.Containers = info.Containers.add(imageVulns[cont.Info.ImageID])
+	}
+	return info, nil
+}
+
+// add returns sum of two counts
+func (v VulnerabilityCounts) add(other VulnerabilityCounts) VulnerabilityCounts {
+	v.Critical += other.Critical
+	v.High += other.High
+	v.Medium += other.Medium
+	v.Low += other.Low
+	return v
+}
+
+// computeList walks through all pages of Compute list endpoint and stores all entries to result slice pointer
+func computeList[T any](api apiCaller, path string, result *[]T) error {
+	for offset := 0; ; offset += computePageSize {
+		data, err := api.Call("GET", fmt.Sprintf("%s?limit=%d&offset=%d", path, computePageSize, offset), nil)
+		if err != nil {
+			return err
+		}
+		var page []T
+		if err := json.Unmarshal(data, &page); err != nil {
+			return fmt.Errorf("error unmarshaling response: %w", err)
+		}
+		*result = append(*result, page...)
+		if len(page) < computePageSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2019 Booking.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompute_GatherDefendersInfo(t *testing.T) {
+	// full page of defenders to check that next page is requested
+	fullPage := "[" + strings.TrimSuffix(strings.Repeat(`{"hostname":"h","type":"daemonset","connected":true},`, computePageSize), ",") + "]"
+	var testAPIRequestsDataset = []struct {
+		firstPage  mockResponse
+		secondPage mockResponse
+		error      string
+		info       *DefendersInfo
+	}{
+		{firstPage: mockResponse{err: fmt.Errorf("mock error")},
+			error: "error requesting defenders information: mock error"},
+		{firstPage: mockResponse{answer: []byte("not_json")},
+			error: "error requesting defenders information: error unmarshaling response: invalid character 'o' in literal null (expecting 'u')"},
+		{firstPage: mockResponse{answer: []byte(fullPage)},
+			secondPage: mockResponse{answer: []byte(`[{"hostname":"h","type":"docker","connected":false}]`)},
+			info:       &DefendersInfo{Connected: map[string]int{"daemonset": computePageSize}, Disconnected: map[string]int{"docker": 1}}},
+	}
+
+	// start tests
+	c := &Compute{}
+
+	for i, x := range testAPIRequestsDataset {
+		c.api = &mockRoutes{t: t, routes: map[string]mockResponse{
+			"GET /api/v1/defenders?limit=50&offset=0":  x.firstPage,
+			"GET /api/v1/defenders?limit=50&offset=50": x.secondPage,
+		}}
+		info, err := c.GatherDefendersInfo()
+		if x.error != "" {
+			assert.EqualError(t, err, x.error, "Test case %d error check failed", i)
+		} else {
+			assert.NoError(t, err, "Test case %d error check failed", i)
+		}
+		assert.Equal(t, x.info, info, "Test case %d defenders info check failed", i)
+	}
+}
+
+func TestCompute_GatherVulnerabilityInfo(t *testing.T) {
+	var testAPIRequestsDataset = []struct {
+		hosts      mockResponse
+		images     mockResponse
+		containers mockResponse
+		error      string
+		info       *VulnerabilityInfo
+	}{
+		{hosts: mockResponse{err: fmt.Errorf("mock error")},
+			error: "error requesting hosts vulnerabilities: mock error"},
+		{hosts: mockResponse{answer: []byte(`[]`)}, images: mockResponse{err: fmt.Errorf("mock error")},
+			error: "error requesting images vulnerabilities: mock error"},
+		{hosts: mockResponse{answer: []byte(`[]`)}, images: mockResponse{answer: []byte(`[]`)},
+			containers: mockResponse{err: fmt.Errorf("mock error")},
+			error:      "error requesting containers information: mock error"},
+		{hosts: mockResponse{answer: []byte(`[
+{"_id":"host1","vulnerabilityDistribution":{"critical":1,"high":2,"medium":3,"low":4}},
+{"_id":"host2","vulnerabilityDistribution":{"critical":1}}]`)},
+			images: mockResponse{answer: []byte(`[
+{"_id":"image1","vulnerabilityDistribution":{"high":1,"low":2}},
+{"_id":"image2","vulnerabilityDistribution":{"critical":5}}]`)},
+			containers: mockResponse{answer: []byte(`[
+{"_id":"container1","info":{"imageID":"image1"}},
+{"_id":"container2","info":{"imageID":"image1"}},
+{"_id":"container3","info":{"imageID":"not_scanned"}}]`)},
+			info: &VulnerabilityInfo{
+				Hosts:      VulnerabilityCounts{Critical: 2, High: 2, Medium: 3, Low: 4},
+				Images:     VulnerabilityCounts{Critical: 5, High: 1, Low: 2},
+				Containers: VulnerabilityCounts{High: 2, Low: 4}}},
+	}
+
+	// start tests
+	c := &Compute{}
+
+	for i, x := range testAPIRequestsDataset {
+		c.api = &mockRoutes{t: t, routes: map[string]mockResponse{
+			"GET /api/v1/hosts?limit=50&offset=0":      x.hosts,
+			"GET /api/v1/images?limit=50&offset=0":     x.images,
+			"GET /api/v1/containers?limit=50&offset=0": x.containers,
+		}}
+		info, err := c.GatherVulnerabilityInfo()
+		if x.error != "" {
+			assert.EqualError(t, err, x.error, "Test case %d error check failed", i)
+		} else {
+			assert.NoError(t, err, "Test case %d error check failed", i)
+		}
+		assert.Equal(t, x.info, info, "Test case %d vulnerability info check failed", i)
+	}
+}
+
+func TestComputeClient_Call(t *testing.T) {
+	logins := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/authenticate":
+			assert.Equal(t, "POST", r.Method)
+			logins++
+			_, _ = fmt.Fprintf(w, `{"token":"token%d"}`, logins)
+		case "/api/v1/defenders":
+			_, _ = fmt.Fprint(w, r.Header.Get("Authorization"))
+		case "/api/v1/revoked":
+			w.WriteHeader(http.StatusUnauthorized)
+		case "/api/v1/limited":
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	c := newComputeClient("user", "password", server.URL)
+	data, err := c.Call("GET", "/api/v1/defenders", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer token1", string(data))
+	_, err = c.Call("GET", "/nonexistent", nil)
+	assert.EqualError(t, err, `404 Not Found, response body: ""`)
+	assert.Equal(t, 1, logins, "Token is reused between calls")
+	_, err = c.Call("GET", "/api/v1/revoked", nil)
+	assert.EqualError(t, err, `401 Unauthorized, response body: ""`)
+	assert.Equal(t, 2, logins, "Unauthorized request is repeated after new login")
+	data, err = c.Call("GET", "/api/v1/defenders", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer token2", string(data), "Token obtained after authentication error is used")
+
+	// Compute errors are classified the same way as Prisma ones
+	_, err = c.Call("GET", "/api/v1/limited", nil)
+	class, code := classifyAPIError(err)
+	assert.Equal(t, HealthErrorRateLimit, class)
+	assert.Equal(t, http.StatusTooManyRequests, code)
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, time.Second*7, apiErr.RetryAfter)
+
+	_, err = newComputeClient("user", "password", "http://[::1]:namedport").Call("GET", "/", nil)
+	assert.EqualError(t, err, `error getting auth token: error logging in with user "user": error creating request: `+
+		`parse "http://[::1]:namedport/api/v1/authenticate": invalid port ":namedport" after host`)
+}
@@ -22,11 +22,14 @@ import (
 	"log"
 	"net/http"
 	"regexp"
+	"strings"
+	"sync"
 	"time"
 
 	securitycenter "cloud.google.com/go/securitycenter/apiv1"
 	"cloud.google.com/go/securitycenter/apiv1/securitycenterpb"
 	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
 )
 
 type googleStatusEntry struct {
@@ -39,6 +42,31 @@ type googleStatusEntry struct {
 // Google SCC API operations timeout
 const apiTimeout = time.Second * 20
 
+// default number of SCC sources queried at once
+const defaultSCCWorkers = 4
+
+// page size of SCC requests walking through all results, maximum allowed by API
+const sccPageSize = 1000
+
+// findings group state_change value of findings not present or not matching filter at the start of compare duration
+const sccStateChangeAdded = "ADDED"
+
+// SCC findings time fields sources delay could be calculated by
+const (
+	SCCOrderEventTime  = "event_time"
+	SCCOrderCreateTime = "create_time"
+)
+
+// SCCDelayQuery defines which findings are used for sources delay calculation
+type SCCDelayQuery struct {
+	// findings time field, one of SCCOrder* constants, event_time is used when empty
+	OrderBy string
+	// findings filter expression, empty filter matches all findings
+	Filter string
+	// filter expressions per source display name, overriding Filter
+	SourceFilters map[string]string
+}
+
 // GetSCCHealthStatus gets Google Security Command Center health information and returns 1 on healthy response, 0 otherwise
 // Check is performed by fetching list of incidents from Google Cloud Status Dashboard
 // and checking if there are ongoing incidents with cloud-security-command-center;
@@ -74,27 +102,125 @@ func GetSCCHealthStatus(url string) int {
 	return 1
 }
 
-// GetSCCSourcesByName returns Security Command Center sources for given numeric orgID,
-// filtered by name by given regex
+// sccIterator is common part of Security Command Center API iterators
+type sccIterator[T any] interface {
+	Next() (T, error)
+}
+
+// sccCaller is subset of Security Command Center API used by SCC,
+// implemented by sccClient wrapper of securitycenter.Client and by fakes in tests
+type sccCaller interface {
+	ListSources(ctx context.Context, req *securitycenterpb.ListSourcesRequest) sccIterator[*securitycenterpb.Source]
+	ListFindings(ctx context.Context, req *securitycenterpb.ListFindingsRequest) sccIterator[*securitycenterpb.ListFindingsResponse_ListFindingsResult]
+	GroupFindings(ctx context.Context, req *securitycenterpb.GroupFindingsRequest) sccIterator[*securitycenterpb.GroupResult]
+	ListMuteConfigs(ctx context.Context, req *securitycenterpb.ListMuteConfigsRequest) sccIterator[*securitycenterpb.MuteConfig]
+	GroupAssets(ctx context.Context, req *securitycenterpb.GroupAssetsRequest) sccIterator[*securitycenterpb.GroupResult]
+	ListNotificationConfigs(ctx context.Context, req *securitycenterpb.ListNotificationConfigsRequest) sccIterator[*securitycenterpb.NotificationConfig]
+	Close() error
+}
+
+// sccClient adapts securitycenter.Client to sccCaller interface
+type sccClient struct {
+	client *securitycenter.Client
+}
+
+func (c *sccClient) ListSources(ctx context.Context, req *securitycenterpb.ListSourcesRequest) sccIterator[*securitycenterpb.Source] {
+	return c.client.ListSources(ctx, req)
+}
+
+func (c *sccClient) ListFindings(ctx context.Context, req *securitycenterpb.ListFindingsRequest) sccIterator[*securitycenterpb.ListFindingsResponse_ListFindingsResult] {
+	return c.client.ListFindings(ctx, req)
+}
+
+func (c *sccClient) GroupFindings(ctx context.Context, req *securitycenterpb.GroupFindingsRequest) sccIterator[*securitycenterpb.GroupResult] {
+	return c.client.GroupFindings(ctx, req)
+}
+
+func (c *sccClient) ListMuteConfigs(ctx context.Context, req *securitycenterpb.ListMuteConfigsRequest) sccIterator[*securitycenterpb.MuteConfig] {
+	return c.client.ListMuteConfigs(ctx, req)
+}
+
+func (c *sccClient) GroupAssets(ctx context.Context, req *securitycenterpb.GroupAssetsRequest) sccIterator[*securitycenterpb.GroupResult] {
+	// deprecation is documented in README next to scc_assets option
+	return c.client.GroupAssets(ctx, req) // nolint:staticcheck // v1 API has no other assets grouping method
+}
+
+func (c *sccClient) ListNotificationConfigs(ctx context.Context, req *securitycenterpb.ListNotificationConfigsRequest) sccIterator[*securitycenterpb.NotificationConfig] {
+	return c.client.ListNotificationConfigs(ctx, req)
+}
+
+func (c *sccClient) Close() error {
+	return c.client.Close()
+}
+
+// SCC is Google Security Command Center API client, which keeps single connection
+// for its lifetime and should be closed after use
+type SCC struct {
+	api sccCaller
+	// maximum number of sources queried at once
+	workers    int
+	delayQuery SCCDelayQuery
+
+	// folders display names of projects by project display name, kept between GetOwnersFindings calls
+	// as project is rarely moved between folders
+	foldersLock    sync.Mutex
+	projectFolders map[string][]string
+}
+
+// NewSCC creates Security Command Center client using given options, application default credentials are used by default
+func NewSCC(opts ...option.ClientOption) (*SCC, error) {
+	// context is used only for the client creation and must not be canceled while client is in use
+	client, err := securitycenter.NewClient(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("securitycenter.NewClient: %w", err)
+	}
+	return &SCC{api: &sccClient{client: client}, workers: defaultSCCWorkers}, nil
+}
+
+// SetWorkers sets maximum number of sources queried at once, values below 1 are replaced with 1;
+// it should be called before the client is used
+func (s *SCC) SetWorkers(workers int) {
+	if workers < 1 {
+		workers = 1
+	}
+	s.workers = workers
+}
+
+// Close cleans up background resources of the client
+func (s *SCC) Close() error {
+	return s.api.Close()
+}
+
+// SetDelayQuery sets findings query used for sources delay calculation,
+// it should be called before the client is used
+func (s *SCC) SetDelayQuery(q SCCDelayQuery) error {
+	switch q.OrderBy {
+	case "", SCCOrderEventTime, SCCOrderCreateTime:
+	default:
+		return fmt.Errorf("unsupported findings order field %q, should be %s or %s", q.OrderBy, SCCOrderEventTime, SCCOrderCreateTime)
+	}
+	s.delayQuery = q
+	return nil
+}
+
+// GetSourcesByName returns Security Command Center sources for given parent, which is numeric organisation ID
+// or organizations/, folders/ or projects/ resource name, filtered by name by given regex.
+// Sources belong to the organisation, so for folder and project parent their IDs are rewritten
+// to be nested under the parent, like folders/1/sources/2, which limits findings queries to the parent
 // original: https://github.com/GoogleCloudPlatform/golang-samples/blob/master/securitycenter/findings/list_sources.go
-func GetSCCSourcesByName(orgID string, nameRegex string) (map[string]string, error) {
+func (s *SCC) GetSourcesByName(parent string, nameRegex string) (map[string]string, error) {
 	regex, err := regexp.Compile(nameRegex)
 	if err != nil {
 		return nil, fmt.Errorf("error compiling nameRegex: %w", err)
 	}
-	// Instantiate a context and a security service client to make API calls.
 	ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
 	defer cancel()
-	client, err := securitycenter.NewClient(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("securitycenter.NewClient: %w", err)
-	}
-	defer client.Close() // Closing the client safely cleans up background resources.
 
+	parent = sccParent(parent)
 	req := &securitycenterpb.ListSourcesRequest{
-		Parent: fmt.Sprintf("organizations/%s", orgID),
+		Parent: parent,
 	}
-	it := client.ListSources(ctx, req)
+	it := s.api.ListSources(ctx, req)
 	result := map[string]string{}
 	for {
 		source, err := it.Next()
@@ -106,42 +232,97 @@ func GetSCCSourcesByName(orgID string, nameRegex string) (map[string]string, err
 		}
 
 		if match := regex.MatchString(source.DisplayName); match {
-			result[source.Name] = source.DisplayName
+			id := source.Name
+			if !strings.HasPrefix(parent, "organizations/") {
+				id = parent + "/sources/" + lastPathElement(source.Name)
+			}
+			result[id] = source.DisplayName
 		}
 	}
 	return result, nil
 }
 
-// GetSCCLatestEventTime return map of sources and their latest event update time difference with now
+// GetLatestEventTime returns map of sources names and difference between now and the latest time of their findings
+// selected by query set with SetDelayQuery, along with errors of sources which failed to be queried;
+// sources are queried concurrently
 // original: https://github.com/GoogleCloudPlatform/golang-samples/blob/master/securitycenter/findings/list_filtered_findings.go
-func GetSCCLatestEventTime(sources map[string]string) (map[string]time.Duration, error) {
+func (s *SCC) GetLatestEventTime(sources map[string]string) (map[string]time.Duration, map[string]error) {
 	result := make(map[string]time.Duration)
-	ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
-	defer cancel()
-	client, err := securitycenter.NewClient(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("securitycenter.NewClient: %w", err)
+	orderBy := s.delayQuery.OrderBy
+	if orderBy == "" {
+		orderBy = SCCOrderEventTime
 	}
-	defer client.Close() // Closing the client safely cleans up background resources.
+	var mu sync.Mutex
 
 	// process just one event with newest update date for every given source
-	for id, name := range sources {
+	errs := s.forEachSource(sources, func(ctx context.Context, id, name string) error {
+		filter, ok := s.delayQuery.SourceFilters[name]
+		if !ok {
+			filter = s.delayQuery.Filter
+		}
 		req := &securitycenterpb.ListFindingsRequest{
 			Parent:   id,
-			OrderBy:  `event_time desc`,
+			Filter:   filter,
+			OrderBy:  orderBy + " desc",
 			PageSize: 1,
 		}
-		it := client.ListFindings(ctx, req)
+		it := s.api.ListFindings(ctx, req)
 		// we are getting first page with single element and discard other results
 		findingsResult, err := it.Next()
 		if err == iterator.Done {
-			continue
+			return nil
 		}
 		if err != nil {
-			return nil, fmt.Errorf("events iterator problem: %w", err)
+			return fmt.Errorf("events iterator problem: %w", err)
+		}
+		latest := findingsResult.GetFinding().GetEventTime()
+		if orderBy == SCCOrderCreateTime {
+			latest = findingsResult.GetFinding().GetCreateTime()
+		}
+		if latest == nil {
+			return fmt.Errorf("latest finding has no %s set", orderBy)
 		}
-		finding := findingsResult.Finding
-		result[name] = time.Since(time.Unix(finding.EventTime.Seconds, int64(finding.EventTime.Nanos)))
+		mu.Lock()
+		result[name] = time.Since(latest.AsTime())
+		mu.Unlock()
+		return nil
+	})
+	return result, errs
+}
+
+// sccParent returns SCC API parent resource name for given numeric organisation ID or resource name
+func sccParent(parent string) string {
+	if strings.Contains(parent, "/") {
+		return parent
 	}
-	return result, nil
+	return fmt.Sprintf("organizations/%s", parent)
+}
+
+// forEachSource calls fn with ID and name of every given source, running up to SCC workers number of calls at once,
+// and returns errors returned by fn mapped by source name; every call gets own context limited by apiTimeout,
+// so slow source doesn't leave no time for others
+func (s *SCC) forEachSource(sources map[string]string, fn func(ctx context.Context, id, name string) error) map[string]error {
+	errs := map[string]error{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, max(s.workers, 1))
+	for id, name := range sources {
+		wg.Add(1)
+		workers <- struct{}{}
+		go func(id, name string) {
+			ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+			defer func() {
+				cancel()
+				<-workers
+				wg.Done()
+			}()
+			if err := fn(ctx, id, name); err != nil {
+				mu.Lock()
+				errs[name] = err
+				mu.Unlock()
+			}
+		}(id, name)
+	}
+	wg.Wait()
+	return errs
 }
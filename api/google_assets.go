@@ -0,0 +1,80 @@
+// Copyright 2019 Booking.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"cloud.google.com/go/securitycenter/apiv1/securitycenterpb"
+	"google.golang.org/api/iterator"
+)
+
+// SCC assets grouping fields
+const (
+	sccAssetTypeField    = "security_center_properties.resource_type"
+	sccAssetProjectField = "security_center_properties.resource_project_display_name"
+)
+
+// SCCNoProject is reported as project of assets which don't belong to any project, like organization and folders
+const SCCNoProject = "no_project"
+
+// SCCAssetCount stores number of assets of single resource type in single project
+type SCCAssetCount struct {
+	ResourceType string
+	Project      string
+	Count        int64
+}
+
+// GetAssetCounts returns assets count of given parent, which is numeric organisation ID or resource name,
+// grouped by resource type and project display name
+// https://cloud.google.com/security-command-center/docs/reference/rest/v1/organizations.assets/group
+func (s *SCC) GetAssetCounts(parent string) ([]SCCAssetCount, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+	defer cancel()
+
+	req := &securitycenterpb.GroupAssetsRequest{
+		Parent:  sccParent(parent),
+		GroupBy: sccAssetTypeField + "," + sccAssetProjectField,
+	}
+	it := s.api.GroupAssets(ctx, req)
+	var result []SCCAssetCount
+	for {
+		group, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("assets groups iterator problem: %w", err)
+		}
+		project := group.GetProperties()[sccAssetProjectField].GetStringValue()
+		if project == "" {
+			project = SCCNoProject
+		}
+		result = append(result, SCCAssetCount{
+			ResourceType: group.GetProperties()[sccAssetTypeField].GetStringValue(),
+			Project:      project,
+			Count:        group.GetCount(),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].ResourceType != result[j].ResourceType {
+			return result[i].ResourceType < result[j].ResourceType
+		}
+		return result[i].Project < result[j].Project
+	})
+	return result, nil
+}
@@ -0,0 +1,53 @@
+// Copyright 2019 Booking.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"testing"
+
+	"cloud.google.com/go/securitycenter/apiv1/securitycenterpb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestSCC_GetAssetCounts(t *testing.T) {
+	group := func(resourceType, project string, count int64) *securitycenterpb.GroupResult {
+		return &securitycenterpb.GroupResult{Count: count, Properties: map[string]*structpb.Value{
+			sccAssetTypeField: structpb.NewStringValue(resourceType), sccAssetProjectField: structpb.NewStringValue(project)}}
+	}
+	m := &mockSCC{groups: map[string][]*securitycenterpb.GroupResult{
+		"organizations/1": {
+			group("google.compute.Instance", "web", 10),
+			group("google.cloud.resourcemanager.Folder", "", 2),
+			group("google.compute.Instance", "db", 3)},
+	}}
+	scc := &SCC{api: m}
+	counts, err := scc.GetAssetCounts("1")
+	assert.NoError(t, err)
+	assert.Equal(t, []SCCAssetCount{
+		{ResourceType: "google.cloud.resourcemanager.Folder", Project: SCCNoProject, Count: 2},
+		{ResourceType: "google.compute.Instance", Project: "db", Count: 3},
+		{ResourceType: "google.compute.Instance", Project: "web", Count: 10},
+	}, counts)
+	assert.Equal(t, &securitycenterpb.GroupAssetsRequest{Parent: "organizations/1",
+		GroupBy: "security_center_properties.resource_type,security_center_properties.resource_project_display_name"},
+		m.requests["organizations/1"])
+
+	m.errors = map[string]error{"organizations/1": fmt.Errorf("mock error")}
+	counts, err = scc.GetAssetCounts("1")
+	assert.EqualError(t, err, "assets groups iterator problem: mock error")
+	assert.Nil(t, counts)
+}
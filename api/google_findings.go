@@ -0,0 +1,95 @@
+// Copyright 2019 Booking.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"cloud.google.com/go/securitycenter/apiv1/securitycenterpb"
+	"google.golang.org/api/iterator"
+)
+
+// SCCFindingsCount stores number of active findings of single source with given category and severity
+type SCCFindingsCount struct {
+	Source   string
+	Category string
+	Severity string
+	Count    int64
+}
+
+// GetFindingsCounts returns active findings count of given sources grouped by category and severity,
+// along with errors of sources which failed to be queried; sources map is the one returned by GetSourcesByName
+// https://cloud.google.com/security-command-center/docs/reference/rest/v1/organizations.sources.findings/group
+func (s *SCC) GetFindingsCounts(sources map[string]string) ([]SCCFindingsCount, map[string]error) {
+	var result []SCCFindingsCount
+	var mu sync.Mutex
+	errs := s.forEachSource(sources, func(ctx context.Context, id, name string) error {
+		req := &securitycenterpb.GroupFindingsRequest{
+			Parent:  id,
+			Filter:  `state="ACTIVE"`,
+			GroupBy: "category,severity",
+		}
+		it := s.api.GroupFindings(ctx, req)
+		var counts []SCCFindingsCount
+		for {
+			group, err := it.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("findings groups iterator problem: %w", err)
+			}
+			counts = append(counts, findingsGroupCount(name, group))
+		}
+		mu.Lock()
+		result = append(result, counts...)
+		mu.Unlock()
+		return nil
+	})
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Source != result[j].Source {
+			return result[i].Source < result[j].Source
+		}
+		if result[i].Category != result[j].Category {
+			return result[i].Category < result[j].Category
+		}
+		return result[i].Severity < result[j].Severity
+	})
+	return result, errs
+}
+
+// findingsGroupCount converts category and severity findings group of given source to SCCFindingsCount
+func findingsGroupCount(source string, group *securitycenterpb.GroupResult) SCCFindingsCount {
+	return SCCFindingsCount{
+		Source:   source,
+		Category: group.GetProperties()["category"].GetStringValue(),
+		Severity: groupSeverity(group),
+		Count:    group.GetCount(),
+	}
+}
+
+// groupSeverity returns lowercased severity of given findings group,
+// findings without one are reported with "severity_unspecified"
+func groupSeverity(group *securitycenterpb.GroupResult) string {
+	severity := strings.ToLower(group.GetProperties()["severity"].GetStringValue())
+	if severity == "" {
+		severity = strings.ToLower(securitycenterpb.Finding_SEVERITY_UNSPECIFIED.String())
+	}
+	return severity
+}
@@ -0,0 +1,73 @@
+// Copyright 2019 Booking.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"testing"
+
+	"cloud.google.com/go/securitycenter/apiv1/securitycenterpb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestSCC_GetFindingsCounts(t *testing.T) {
+	group := func(category, severity string, count int64) *securitycenterpb.GroupResult {
+		return &securitycenterpb.GroupResult{Count: count, Properties: map[string]*structpb.Value{
+			"category": structpb.NewStringValue(category), "severity": structpb.NewStringValue(severity)}}
+	}
+	m := &mockSCC{groups: map[string][]*securitycenterpb.GroupResult{
+		"sources/1": {group("OPEN_FIREWALL", "HIGH", 2), group("MFA_NOT_ENFORCED", "MEDIUM", 1)},
+		"sources/2": {group("OPEN_FIREWALL", "HIGH", 3)},
+	}}
+	scc := &SCC{api: m}
+	counts, errs := scc.GetFindingsCounts(map[string]string{"sources/1": "SHA", "sources/2": "Forseti"})
+	assert.Empty(t, errs)
+	assert.Equal(t, []SCCFindingsCount{
+		{Source: "Forseti", Category: "OPEN_FIREWALL", Severity: "high", Count: 3},
+		{Source: "SHA", Category: "MFA_NOT_ENFORCED", Severity: "medium", Count: 1},
+		{Source: "SHA", Category: "OPEN_FIREWALL", Severity: "high", Count: 2},
+	}, counts)
+	assert.Equal(t, &securitycenterpb.GroupFindingsRequest{Parent: "sources/1", Filter: `state="ACTIVE"`, GroupBy: "category,severity"},
+		m.requests["sources/1"])
+
+	m.errors = map[string]error{"sources/2": fmt.Errorf("mock error")}
+	counts, errs = scc.GetFindingsCounts(map[string]string{"sources/1": "SHA", "sources/2": "Forseti"})
+	assert.Len(t, errs, 1)
+	assert.EqualError(t, errs["Forseti"], "findings groups iterator problem: mock error")
+	assert.Equal(t, []SCCFindingsCount{
+		{Source: "SHA", Category: "MFA_NOT_ENFORCED", Severity: "medium", Count: 1},
+		{Source: "SHA", Category: "OPEN_FIREWALL", Severity: "high", Count: 2},
+	}, counts, "Failed source doesn't affect counts of others")
+}
+
+func TestFindingsGroupCount(t *testing.T) {
+	var testDataset = []struct {
+		group *securitycenterpb.GroupResult
+		count SCCFindingsCount
+	}{
+		{group: &securitycenterpb.GroupResult{Count: 5, Properties: map[string]*structpb.Value{
+			"category": structpb.NewStringValue("PUBLIC_BUCKET_ACL"), "severity": structpb.NewStringValue("HIGH")}},
+			count: SCCFindingsCount{Source: "SHA", Category: "PUBLIC_BUCKET_ACL", Severity: "high", Count: 5}},
+		{group: &securitycenterpb.GroupResult{Count: 1, Properties: map[string]*structpb.Value{
+			"category": structpb.NewStringValue("custom")}},
+			count: SCCFindingsCount{Source: "SHA", Category: "custom", Severity: "severity_unspecified", Count: 1}},
+		{group: &securitycenterpb.GroupResult{},
+			count: SCCFindingsCount{Source: "SHA", Severity: "severity_unspecified"}},
+	}
+	for i, x := range testDataset {
+		assert.Equal(t, x.count, findingsGroupCount("SHA", x.group), "Test case %d count check failed", i)
+	}
+}
@@ -0,0 +1,212 @@
+// Copyright 2019 Booking.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/securitycenter/apiv1/securitycenterpb"
+	"google.golang.org/api/iterator"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// SCCFindingsLifecycle stores findings lifecycle statistics for single source and severity
+type SCCFindingsLifecycle struct {
+	Source   string
+	Severity string
+	// findings created within the window, including ones already resolved
+	Created int
+	// findings which became inactive within the window
+	Resolved       int
+	OpenLess1Day   int
+	Open1To7Days   int
+	Open7To30Days  int
+	OpenOver30Days int
+	// time to remediate statistics of findings resolved within the window
+	MeanTimeToRemediate time.Duration
+	P50TimeToRemediate  time.Duration
+	P90TimeToRemediate  time.Duration
+	P99TimeToRemediate  time.Duration
+}
+
+// GetFindingsLifecycle returns per source and severity counts of findings created and resolved within given window,
+// open findings age distribution and time to remediate statistics, along with errors of sources which failed to be queried.
+// Counts are grouped by SCC: finding is created within window when it wasn't present at its start,
+// and resolved within window when it was added to inactive findings during it, which is the case for findings
+// resolved within window as well as for ones both created and resolved within it.
+// Time to remediate is the time between finding creation and its event time: SCC doesn't report resolution time,
+// and event time is the time finding source last detected or updated the finding, so it matches resolution time
+// only for sources which update the finding on resolution, and is earlier than that for the rest.
+// To keep listing bounded, it's calculated only for findings resolved within window with event time within it.
+// https://cloud.google.com/security-command-center/docs/reference/rest/v1/organizations.sources.findings/group
+// https://cloud.google.com/security-command-center/docs/reference/rest/v1/organizations.sources.findings/list
+func (s *SCC) GetFindingsLifecycle(sources map[string]string, window time.Duration) ([]SCCFindingsLifecycle, map[string]error) {
+	return s.getFindingsLifecycle(sources, window, time.Now())
+}
+
+// getFindingsLifecycle is GetFindingsLifecycle with findings age calculated relative to given time
+func (s *SCC) getFindingsLifecycle(sources map[string]string, window time.Duration, now time.Time) ([]SCCFindingsLifecycle, map[string]error) {
+	var result []SCCFindingsLifecycle
+	var mu sync.Mutex
+	errs := s.forEachSource(sources, func(ctx context.Context, id, name string) error {
+		lifecycle := newFindingsLifecycle(name)
+		groups := s.api.GroupFindings(ctx, &securitycenterpb.GroupFindingsRequest{Parent: id,
+			GroupBy: "severity,state_change", CompareDuration: durationpb.New(window), PageSize: sccPageSize})
+		if err := walkIterator(groups, lifecycle.addCreated); err != nil {
+			return fmt.Errorf("created findings groups iterator problem: %w", err)
+		}
+		groups = s.api.GroupFindings(ctx, &securitycenterpb.GroupFindingsRequest{Parent: id, Filter: `state="INACTIVE"`,
+			GroupBy: "severity,state_change", CompareDuration: durationpb.New(window), PageSize: sccPageSize})
+		if err := walkIterator(groups, lifecycle.addResolved); err != nil {
+			return fmt.Errorf("resolved findings groups iterator problem: %w", err)
+		}
+		for _, bucket := range openAgeBuckets() {
+			filter := `state="ACTIVE"`
+			if bucket.from != 0 {
+				filter += fmt.Sprintf(" AND create_time <= %d", now.Add(-bucket.from).UnixMilli())
+			}
+			if bucket.to != 0 {
+				filter += fmt.Sprintf(" AND create_time > %d", now.Add(-bucket.to).UnixMilli())
+			}
+			groups = s.api.GroupFindings(ctx, &securitycenterpb.GroupFindingsRequest{Parent: id, Filter: filter,
+				GroupBy: "severity", PageSize: sccPageSize})
+			if err := walkIterator(groups, func(group *securitycenterpb.GroupResult) {
+				*bucket.count(lifecycle.get(groupSeverity(group))) += int(group.GetCount())
+			}); err != nil {
+				return fmt.Errorf("open findings groups iterator problem: %w", err)
+			}
+		}
+		it := s.api.ListFindings(ctx, &securitycenterpb.ListFindingsRequest{Parent: id,
+			Filter:          fmt.Sprintf(`state="INACTIVE" AND event_time >= %d`, now.Add(-window).UnixMilli()),
+			CompareDuration: durationpb.New(window), PageSize: sccPageSize})
+		if err := walkIterator(it, lifecycle.addRemediated); err != nil {
+			return fmt.Errorf("resolved findings iterator problem: %w", err)
+		}
+		mu.Lock()
+		result = append(result, lifecycle.result()...)
+		mu.Unlock()
+		return nil
+	})
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Source != result[j].Source {
+			return result[i].Source < result[j].Source
+		}
+		return result[i].Severity < result[j].Severity
+	})
+	return result, errs
+}
+
+// openAgeBucket is open findings age range, from inclusive and to exclusive, zero to means no upper bound,
+// along with the counter of findings within it
+type openAgeBucket struct {
+	from  time.Duration
+	to    time.Duration
+	count func(*SCCFindingsLifecycle) *int
+}
+
+// openAgeBuckets returns buckets of SCCFindingsLifecycle open findings age distribution
+func openAgeBuckets() []openAgeBucket {
+	return []openAgeBucket{
+		{to: day, count: func(l *SCCFindingsLifecycle) *int { return &l.OpenLess1Day }},
+		{from: day, to: day * 7, count: func(l *SCCFindingsLifecycle) *int { return &l.Open1To7Days }},
+		{from: day * 7, to: day * 30, count: func(l *SCCFindingsLifecycle) *int { return &l.Open7To30Days }},
+		{from: day * 30, count: func(l *SCCFindingsLifecycle) *int { return &l.OpenOver30Days }},
+	}
+}
+
+// walkIterator calls fn for every item returned by given iterator
+func walkIterator[T any](it sccIterator[T], fn func(T)) error {
+	for {
+		item, err := it.Next()
+		if err == iterator.Done {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		fn(item)
+	}
+}
+
+// findingsLifecycle aggregates findings of single source by severity
+type findingsLifecycle struct {
+	source         string
+	bySeverity     map[string]*SCCFindingsLifecycle
+	remediateTimes map[string][]time.Duration
+}
+
+func newFindingsLifecycle(source string) *findingsLifecycle {
+	return &findingsLifecycle{source: source,
+		bySeverity: map[string]*SCCFindingsLifecycle{}, remediateTimes: map[string][]time.Duration{}}
+}
+
+func (l *findingsLifecycle) get(severity string) *SCCFindingsLifecycle {
+	if _, ok := l.bySeverity[severity]; !ok {
+		l.bySeverity[severity] = &SCCFindingsLifecycle{Source: l.source, Severity: severity}
+	}
+	return l.bySeverity[severity]
+}
+
+// addCreated counts findings of given group of all findings which weren't present at the start of the window
+func (l *findingsLifecycle) addCreated(group *securitycenterpb.GroupResult) {
+	if group.GetProperties()["state_change"].GetStringValue() == sccStateChangeAdded {
+		l.get(groupSeverity(group)).Created += int(group.GetCount())
+	}
+}
+
+// addResolved counts findings of given group of inactive findings which weren't inactive at the start of the window
+func (l *findingsLifecycle) addResolved(group *securitycenterpb.GroupResult) {
+	if group.GetProperties()["state_change"].GetStringValue() == sccStateChangeAdded {
+		l.get(groupSeverity(group)).Resolved += int(group.GetCount())
+	}
+}
+
+// addRemediated records time to remediate of given inactive finding when it wasn't inactive at the start of the window
+func (l *findingsLifecycle) addRemediated(r *securitycenterpb.ListFindingsResponse_ListFindingsResult) {
+	if r.GetStateChange() != securitycenterpb.ListFindingsResponse_ListFindingsResult_ADDED {
+		return
+	}
+	severity := strings.ToLower(r.GetFinding().GetSeverity().String())
+	l.get(severity)
+	l.remediateTimes[severity] = append(l.remediateTimes[severity],
+		r.GetFinding().GetEventTime().AsTime().Sub(r.GetFinding().GetCreateTime().AsTime()))
+}
+
+// result returns aggregated statistics sorted by severity
+func (l *findingsLifecycle) result() []SCCFindingsLifecycle {
+	for severity, durations := range l.remediateTimes {
+		info := l.bySeverity[severity]
+		sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+		var total time.Duration
+		for _, d := range durations {
+			total += d
+		}
+		info.MeanTimeToRemediate = total / time.Duration(len(durations))
+		info.P50TimeToRemediate = percentile(durations, 50)
+		info.P90TimeToRemediate = percentile(durations, 90)
+		info.P99TimeToRemediate = percentile(durations, 99)
+	}
+	result := make([]SCCFindingsLifecycle, 0, len(l.bySeverity))
+	for _, info := range l.bySeverity {
+		result = append(result, *info)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Severity < result[j].Severity })
+	return result
+}
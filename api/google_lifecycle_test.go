@@ -0,0 +1,93 @@
+// Copyright 2019 Booking.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/securitycenter/apiv1/securitycenterpb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestSCC_GetFindingsLifecycle(t *testing.T) {
+	now := time.Now()
+	ms := func(age time.Duration) int64 { return now.Add(-age).UnixMilli() }
+	group := func(severity, stateChange string, count int64) *securitycenterpb.GroupResult {
+		g := &securitycenterpb.GroupResult{Count: count, Properties: map[string]*structpb.Value{
+			"severity": structpb.NewStringValue(severity)}}
+		if stateChange != "" {
+			g.Properties["state_change"] = structpb.NewStringValue(stateChange)
+		}
+		return g
+	}
+	finding := func(severity securitycenterpb.Finding_Severity, created, event time.Duration,
+		change securitycenterpb.ListFindingsResponse_ListFindingsResult_StateChange) *securitycenterpb.ListFindingsResponse_ListFindingsResult {
+		return &securitycenterpb.ListFindingsResponse_ListFindingsResult{StateChange: change,
+			Finding: &securitycenterpb.Finding{Severity: severity,
+				CreateTime: timestamppb.New(now.Add(-created)), EventTime: timestamppb.New(now.Add(-event))}}
+	}
+	inactiveFilter := fmt.Sprintf(`state="INACTIVE" AND event_time >= %d`, ms(day))
+	m := &mockSCC{
+		groups: map[string][]*securitycenterpb.GroupResult{
+			"sources/1 ": {group("HIGH", "ADDED", 1), group("HIGH", "UNCHANGED", 7), group("MEDIUM", "ADDED", 1)},
+			`sources/1 state="INACTIVE"`: {group("HIGH", "ADDED", 2), group("MEDIUM", "ADDED", 1),
+				group("CRITICAL", "UNCHANGED", 1)},
+			fmt.Sprintf(`sources/1 state="ACTIVE" AND create_time > %d`, ms(day)): {group("HIGH", "", 1)},
+			fmt.Sprintf(`sources/1 state="ACTIVE" AND create_time <= %d AND create_time > %d`, ms(day), ms(day*7)): {
+				group("HIGH", "", 1)},
+			fmt.Sprintf(`sources/1 state="ACTIVE" AND create_time <= %d AND create_time > %d`, ms(day*7), ms(day*30)): {
+				group("LOW", "", 1)},
+			fmt.Sprintf(`sources/1 state="ACTIVE" AND create_time <= %d`, ms(day*30)): {group("LOW", "", 1)},
+		},
+		findings: map[string][]*securitycenterpb.ListFindingsResponse_ListFindingsResult{
+			"sources/1 " + inactiveFilter: {
+				// active at the window start, resolved within it
+				finding(securitycenterpb.Finding_HIGH, day*2, time.Hour, securitycenterpb.ListFindingsResponse_ListFindingsResult_ADDED),
+				finding(securitycenterpb.Finding_HIGH, day*5, day-time.Minute, securitycenterpb.ListFindingsResponse_ListFindingsResult_ADDED),
+				// created and resolved within the window
+				finding(securitycenterpb.Finding_MEDIUM, time.Hour*5, time.Hour, securitycenterpb.ListFindingsResponse_ListFindingsResult_ADDED),
+				// inactive at the window start and updated within it
+				finding(securitycenterpb.Finding_CRITICAL, day*5, time.Hour, securitycenterpb.ListFindingsResponse_ListFindingsResult_UNCHANGED),
+			},
+		},
+		errors: map[string]error{
+			"sources/2":                   fmt.Errorf("mock error"),
+			`sources/3 state="INACTIVE"`:  fmt.Errorf("mock error"),
+			"sources/4 " + inactiveFilter: fmt.Errorf("mock error"),
+		}}
+	scc := &SCC{api: m, workers: 2}
+	lifecycle, errs := scc.getFindingsLifecycle(map[string]string{"sources/1": "SHA", "sources/2": "broken",
+		"sources/3": "broken resolved", "sources/4": "broken remediated"}, day, now)
+	assert.Len(t, errs, 3)
+	assert.EqualError(t, errs["broken"], "created findings groups iterator problem: mock error")
+	assert.EqualError(t, errs["broken resolved"], "resolved findings groups iterator problem: mock error")
+	assert.EqualError(t, errs["broken remediated"], "resolved findings iterator problem: mock error")
+	remediate := []time.Duration{day*2 - time.Hour, day*4 + time.Minute}
+	assert.Equal(t, []SCCFindingsLifecycle{
+		{Source: "SHA", Severity: "high", Created: 1, Resolved: 2, OpenLess1Day: 1, Open1To7Days: 1,
+			MeanTimeToRemediate: (remediate[0] + remediate[1]) / 2,
+			P50TimeToRemediate:  remediate[0], P90TimeToRemediate: remediate[1], P99TimeToRemediate: remediate[1]},
+		{Source: "SHA", Severity: "low", Open7To30Days: 1, OpenOver30Days: 1},
+		{Source: "SHA", Severity: "medium", Created: 1, Resolved: 1, MeanTimeToRemediate: time.Hour * 4,
+			P50TimeToRemediate: time.Hour * 4, P90TimeToRemediate: time.Hour * 4, P99TimeToRemediate: time.Hour * 4},
+	}, lifecycle)
+	assert.Equal(t, &securitycenterpb.ListFindingsRequest{Parent: "sources/1", Filter: inactiveFilter,
+		CompareDuration: durationpb.New(day), PageSize: 1000}, m.requests["sources/1"])
+}
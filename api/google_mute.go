@@ -0,0 +1,151 @@
+// Copyright 2019 Booking.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/securitycenter/apiv1/securitycenterpb"
+	"google.golang.org/api/iterator"
+)
+
+// muted findings filter
+const sccMutedFilter = `state="ACTIVE" AND mute="MUTED"`
+
+// SCCMuteInfo stores active muted findings counts and organisation mute configs
+type SCCMuteInfo struct {
+	// muted findings count per category
+	MutedByCategory map[string]int64
+	// muted findings count per mute config ID
+	MutedByConfig map[string]int64
+	// muted findings count of findings muted not by one of Configs
+	MutedManually int64
+	Configs       []SCCMuteConfig
+}
+
+// SCCMuteConfig stores single mute config information
+type SCCMuteConfig struct {
+	ID         string
+	UpdateTime time.Time
+}
+
+// GetMuteInfo returns active muted findings counts of given sources per category and per mute config
+// along with mute configs of given parent, which is numeric organisation ID or resource name,
+// and errors of sources which failed to be queried; error is returned when mute configs can't be listed.
+// Findings are grouped by category, and counted per mute config with filter by mute initiator,
+// findings not muted by any of listed configs are counted as muted manually
+// https://cloud.google.com/security-command-center/docs/reference/rest/v1/organizations.muteConfigs/list
+// https://cloud.google.com/security-command-center/docs/reference/rest/v1/organizations.sources.findings/group
+func (s *SCC) GetMuteInfo(parent string, sources map[string]string) (*SCCMuteInfo, map[string]error, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+	defer cancel()
+
+	result := &SCCMuteInfo{MutedByCategory: map[string]int64{}, MutedByConfig: map[string]int64{}}
+	var configNames []string
+	it := s.api.ListMuteConfigs(ctx, &securitycenterpb.ListMuteConfigsRequest{Parent: sccParent(parent), PageSize: sccPageSize})
+	for {
+		config, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("mute configs iterator problem: %w", err)
+		}
+		result.Configs = append(result.Configs, SCCMuteConfig{ID: lastPathElement(config.GetName()),
+			UpdateTime: config.GetUpdateTime().AsTime()})
+		configNames = append(configNames, config.GetName())
+	}
+
+	var mu sync.Mutex
+	errs := s.forEachSource(sources, func(ctx context.Context, id, _ string) error {
+		source := &SCCMuteInfo{MutedByCategory: map[string]int64{}, MutedByConfig: map[string]int64{}}
+		var total int64
+		if err := s.walkMutedGroups(ctx, id, sccMutedFilter, func(group *securitycenterpb.GroupResult) {
+			source.MutedByCategory[group.GetProperties()["category"].GetStringValue()] += group.GetCount()
+			total += group.GetCount()
+		}); err != nil {
+			return fmt.Errorf("muted findings groups iterator problem: %w", err)
+		}
+		byName := map[string]int64{}
+		for _, name := range configNames {
+			filter := fmt.Sprintf("%s AND mute_initiator:%q", sccMutedFilter, name)
+			if err := s.walkMutedGroups(ctx, id, filter, func(group *securitycenterpb.GroupResult) {
+				byName[name] += group.GetCount()
+			}); err != nil {
+				return fmt.Errorf("config %q muted findings groups iterator problem: %w", lastPathElement(name), err)
+			}
+		}
+		source.MutedManually = total
+		for name, count := range exclusiveMuteCounts(byName) {
+			source.MutedByConfig[lastPathElement(name)] += count
+			source.MutedManually -= count
+		}
+		source.MutedManually = max(source.MutedManually, 0)
+		mu.Lock()
+		defer mu.Unlock()
+		result.merge(source)
+		return nil
+	})
+	return result, errs, nil
+}
+
+// walkMutedGroups calls fn for every category group of findings of given source matching given filter
+func (s *SCC) walkMutedGroups(ctx context.Context, source, filter string, fn func(*securitycenterpb.GroupResult)) error {
+	return walkIterator(s.api.GroupFindings(ctx, &securitycenterpb.GroupFindingsRequest{Parent: source, Filter: filter,
+		GroupBy: "category", PageSize: sccPageSize}), fn)
+}
+
+// exclusiveMuteCounts returns muted findings counts by mute config name given counts of findings which mute initiator
+// contains config name, so that findings muted by config which name contains name of another one, like
+// "muteConfigs/dev-2" for "muteConfigs/dev", are counted only for the config which muted them
+func exclusiveMuteCounts(byName map[string]int64) map[string]int64 {
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	// counts of longer names, which can't be contained in shorter ones, are exclusive already
+	sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+	result := make(map[string]int64, len(byName))
+	for _, name := range names {
+		result[name] = byName[name]
+		for other, count := range result {
+			if other != name && strings.Contains(other, name) {
+				result[name] -= count
+			}
+		}
+	}
+	return result
+}
+
+// merge adds muted findings counts of given info
+func (m *SCCMuteInfo) merge(other *SCCMuteInfo) {
+	for category, count := range other.MutedByCategory {
+		m.MutedByCategory[category] += count
+	}
+	for id, count := range other.MutedByConfig {
+		m.MutedByConfig[id] += count
+	}
+	m.MutedManually += other.MutedManually
+}
+
+// lastPathElement returns part of resource name after the last slash
+func lastPathElement(name string) string {
+	return name[strings.LastIndex(name, "/")+1:]
+}
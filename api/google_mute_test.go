@@ -0,0 +1,86 @@
+// Copyright 2019 Booking.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/securitycenter/apiv1/securitycenterpb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestSCC_GetMuteInfo(t *testing.T) {
+	updated := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	category := func(name string, count int64) *securitycenterpb.GroupResult {
+		return &securitycenterpb.GroupResult{Count: count,
+			Properties: map[string]*structpb.Value{"category": structpb.NewStringValue(name)}}
+	}
+	const muted = `state="ACTIVE" AND mute="MUTED"`
+	byConfig := func(source, id string) string {
+		return fmt.Sprintf(`%s %s AND mute_initiator:"organizations/1/muteConfigs/%s"`, source, muted, id)
+	}
+	m := &mockSCC{
+		muteConfigs: []*securitycenterpb.MuteConfig{
+			{Name: "organizations/1/muteConfigs/dev", UpdateTime: timestamppb.New(updated)},
+			{Name: "organizations/1/muteConfigs/dev-projects", UpdateTime: timestamppb.New(updated)},
+			{Name: "organizations/1/muteConfigs/manual", UpdateTime: timestamppb.New(updated)}},
+		groups: map[string][]*securitycenterpb.GroupResult{
+			"sources/1 " + muted: {category("OPEN_FIREWALL", 4), category("PUBLIC_BUCKET_ACL", 1)},
+			// findings muted by dev-projects config match dev config filter as well
+			byConfig("sources/1", "dev"):          {category("OPEN_FIREWALL", 3)},
+			byConfig("sources/1", "dev-projects"): {category("OPEN_FIREWALL", 2)},
+			byConfig("sources/1", "manual"):       {category("PUBLIC_BUCKET_ACL", 1)},
+			"sources/2 " + muted:                  {category("OPEN_FIREWALL", 1)},
+		}}
+	scc := &SCC{api: m, workers: 2}
+	info, errs, err := scc.GetMuteInfo("1", map[string]string{"sources/1": "SHA", "sources/2": "Forseti"})
+	assert.NoError(t, err)
+	assert.Empty(t, errs)
+	assert.Equal(t, &SCCMuteInfo{
+		MutedByCategory: map[string]int64{"OPEN_FIREWALL": 5, "PUBLIC_BUCKET_ACL": 1},
+		MutedByConfig:   map[string]int64{"dev": 1, "dev-projects": 2, "manual": 1},
+		MutedManually:   2,
+		Configs: []SCCMuteConfig{{ID: "dev", UpdateTime: updated}, {ID: "dev-projects", UpdateTime: updated},
+			{ID: "manual", UpdateTime: updated}},
+	}, info)
+	assert.Equal(t, &securitycenterpb.ListMuteConfigsRequest{Parent: "organizations/1", PageSize: 1000},
+		m.requests["organizations/1"])
+
+	m.errors = map[string]error{"sources/2": fmt.Errorf("mock error"), byConfig("sources/1", "manual"): fmt.Errorf("mock error")}
+	info, errs, err = scc.GetMuteInfo("1", map[string]string{"sources/1": "SHA", "sources/2": "Forseti"})
+	assert.NoError(t, err)
+	assert.Len(t, errs, 2)
+	assert.EqualError(t, errs["Forseti"], "muted findings groups iterator problem: mock error")
+	assert.EqualError(t, errs["SHA"], `config "manual" muted findings groups iterator problem: mock error`)
+	assert.Equal(t, &securitycenterpb.GroupFindingsRequest{Parent: "sources/2", Filter: muted,
+		GroupBy: "category", PageSize: 1000}, m.requests["sources/2"])
+
+	m.errors = map[string]error{"organizations/1": fmt.Errorf("mock error")}
+	info, errs, err = scc.GetMuteInfo("1", nil)
+	assert.EqualError(t, err, "mute configs iterator problem: mock error")
+	assert.Nil(t, errs)
+	assert.Nil(t, info)
+}
+
+func TestExclusiveMuteCounts(t *testing.T) {
+	assert.Equal(t, map[string]int64{"muteConfigs/dev": 1, "muteConfigs/dev-2": 2, "muteConfigs/dev-2x": 3,
+		"muteConfigs/prod": 4}, exclusiveMuteCounts(map[string]int64{"muteConfigs/dev": 6, "muteConfigs/dev-2": 5,
+		"muteConfigs/dev-2x": 3, "muteConfigs/prod": 4}))
+	assert.Empty(t, exclusiveMuteCounts(nil))
+}
@@ -0,0 +1,112 @@
+// Copyright 2019 Booking.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"cloud.google.com/go/securitycenter/apiv1/securitycenterpb"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SCCNotificationHealth stores health of single SCC notification config
+type SCCNotificationHealth struct {
+	ID          string
+	ValidTopic  bool
+	ValidFilter bool
+	// true if flow check was performed and found finding matching config filter
+	FlowChecked bool
+	// time since event of the latest finding matching config filter
+	LatestEvent time.Duration
+	// error of the filter check query other than invalid filter, ValidFilter is false when it's set
+	// as filter validity is unknown
+	CheckError error
+	Healthy    bool
+}
+
+// GetNotificationsHealth returns health of notification configs of given parent, which is numeric organisation ID
+// or resource name, sorted by config ID.
+// Config filter is validated by querying single finding matching it, filter is invalid when the query is rejected
+// by the API, and empty filter matches all findings. Config is healthy when its Pub/Sub topic and filter are valid
+// and, if maxDelay is not zero, the latest finding matching its filter has event time within maxDelay,
+// which means notifications are still sent
+// https://cloud.google.com/security-command-center/docs/reference/rest/v1/organizations.notificationConfigs/list
+func (s *SCC) GetNotificationsHealth(parent string, maxDelay time.Duration) ([]SCCNotificationHealth, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+	defer cancel()
+
+	topicRegex := regexp.MustCompile(`^projects/[^/]+/topics/[^/]+$`)
+	parent = sccParent(parent)
+	it := s.api.ListNotificationConfigs(ctx, &securitycenterpb.ListNotificationConfigsRequest{Parent: parent})
+	var result []SCCNotificationHealth
+	for {
+		config, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("notification configs iterator problem: %w", err)
+		}
+		health := SCCNotificationHealth{
+			ID:         lastPathElement(config.GetName()),
+			ValidTopic: topicRegex.MatchString(config.GetPubsubTopic()),
+		}
+		s.checkNotificationFilter(ctx, parent, config.GetStreamingConfig().GetFilter(), maxDelay, &health)
+		result = append(result, health)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result, nil
+}
+
+// checkNotificationFilter looks up single finding of the organisation matching given filter to validate it
+// and, if maxDelay is not zero, the latest such finding to check that notifications flow; health is updated
+// with the result
+func (s *SCC) checkNotificationFilter(ctx context.Context, parent, filter string, maxDelay time.Duration, health *SCCNotificationHealth) {
+	req := &securitycenterpb.ListFindingsRequest{
+		Parent:   parent + "/sources/-",
+		Filter:   filter,
+		PageSize: 1,
+	}
+	if maxDelay != 0 {
+		req.OrderBy = SCCOrderEventTime + " desc"
+	}
+	latest, err := s.api.ListFindings(ctx, req).Next()
+	switch {
+	case status.Code(err) == codes.InvalidArgument:
+		return
+	case err != nil && err != iterator.Done:
+		health.CheckError = fmt.Errorf("findings iterator problem for notification config %q: %w", health.ID, err)
+		return
+	}
+	health.ValidFilter = true
+	health.Healthy = health.ValidTopic
+	if maxDelay == 0 {
+		return
+	}
+	if err == iterator.Done {
+		// no finding matches the filter, so no notifications are sent
+		health.Healthy = false
+		return
+	}
+	health.FlowChecked = true
+	health.LatestEvent = time.Since(latest.GetFinding().GetEventTime().AsTime())
+	health.Healthy = health.Healthy && health.LatestEvent <= maxDelay
+}
@@ -0,0 +1,107 @@
+// Copyright 2019 Booking.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/securitycenter/apiv1/securitycenterpb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestSCC_GetNotificationsHealth(t *testing.T) {
+	config := func(id, topic, filter string) *securitycenterpb.NotificationConfig {
+		return &securitycenterpb.NotificationConfig{
+			Name:        "organizations/1/notificationConfigs/" + id,
+			PubsubTopic: topic,
+			NotifyConfig: &securitycenterpb.NotificationConfig_StreamingConfig_{
+				StreamingConfig: &securitycenterpb.NotificationConfig_StreamingConfig{Filter: filter}},
+		}
+	}
+	finding := func(ago time.Duration) []*securitycenterpb.ListFindingsResponse_ListFindingsResult {
+		return []*securitycenterpb.ListFindingsResponse_ListFindingsResult{{Finding: &securitycenterpb.Finding{
+			EventTime: timestamppb.New(time.Now().Add(-ago))}}}
+	}
+	const topic = "projects/siem/topics/scc"
+	m := &mockSCC{
+		notificationConfigs: []*securitycenterpb.NotificationConfig{
+			config("siem", topic, `state="ACTIVE"`),
+			config("stale", topic, `category="OPEN_FIREWALL"`),
+			config("bad-topic", "scc", `state="ACTIVE"`),
+			config("no-filter", topic, ""),
+			config("bad-filter", topic, "bad"),
+			config("failing", topic, "failing"),
+			config("quiet", topic, `category="NONE"`),
+		},
+		findings: map[string][]*securitycenterpb.ListFindingsResponse_ListFindingsResult{
+			`organizations/1/sources/- state="ACTIVE"`:           finding(time.Minute),
+			`organizations/1/sources/- category="OPEN_FIREWALL"`: finding(time.Hour * 2),
+			`organizations/1/sources/- `:                         finding(time.Minute * 5),
+		},
+		errors: map[string]error{
+			"organizations/1/sources/- bad":     status.Error(codes.InvalidArgument, "invalid filter"),
+			"organizations/1/sources/- failing": fmt.Errorf("mock error"),
+		}}
+	scc := &SCC{api: m}
+
+	// without flow check filter is validated
+	health, err := scc.GetNotificationsHealth("1", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []SCCNotificationHealth{
+		{ID: "bad-filter", ValidTopic: true},
+		{ID: "bad-topic", ValidFilter: true},
+		{ID: "failing", ValidTopic: true,
+			CheckError: fmt.Errorf(`findings iterator problem for notification config "failing": %w`, fmt.Errorf("mock error"))},
+		{ID: "no-filter", ValidTopic: true, ValidFilter: true, Healthy: true},
+		{ID: "quiet", ValidTopic: true, ValidFilter: true, Healthy: true},
+		{ID: "siem", ValidTopic: true, ValidFilter: true, Healthy: true},
+		{ID: "stale", ValidTopic: true, ValidFilter: true, Healthy: true},
+	}, health)
+	assert.Equal(t, &securitycenterpb.ListFindingsRequest{Parent: "organizations/1/sources/-", Filter: `category="NONE"`,
+		PageSize: 1}, m.requests["organizations/1/sources/-"])
+
+	health, err = scc.GetNotificationsHealth("1", time.Hour)
+	assert.NoError(t, err)
+	assert.Len(t, health, 7)
+	byID := map[string]SCCNotificationHealth{}
+	for _, h := range health {
+		byID[h.ID] = h
+	}
+	assert.Equal(t, SCCNotificationHealth{ID: "bad-filter", ValidTopic: true}, byID["bad-filter"])
+	assert.False(t, byID["bad-topic"].Healthy)
+	assert.True(t, byID["bad-topic"].FlowChecked)
+	assert.EqualError(t, byID["failing"].CheckError, `findings iterator problem for notification config "failing": mock error`)
+	assert.False(t, byID["failing"].Healthy)
+	assert.True(t, byID["no-filter"].Healthy, "Empty filter matches all findings")
+	assert.InDelta(t, time.Minute*5, byID["no-filter"].LatestEvent, float64(time.Second))
+	assert.Equal(t, SCCNotificationHealth{ID: "quiet", ValidTopic: true, ValidFilter: true}, byID["quiet"])
+	assert.True(t, byID["siem"].Healthy)
+	assert.True(t, byID["siem"].FlowChecked)
+	assert.InDelta(t, time.Minute, byID["siem"].LatestEvent, float64(time.Second))
+	assert.False(t, byID["stale"].Healthy)
+	assert.InDelta(t, time.Hour*2, byID["stale"].LatestEvent, float64(time.Second))
+	assert.Equal(t, &securitycenterpb.ListFindingsRequest{Parent: "organizations/1/sources/-", Filter: `category="NONE"`,
+		OrderBy: "event_time desc", PageSize: 1}, m.requests["organizations/1/sources/-"])
+
+	m.errors = map[string]error{"organizations/1": fmt.Errorf("mock error")}
+	health, err = scc.GetNotificationsHealth("1", 0)
+	assert.EqualError(t, err, "notification configs iterator problem: mock error")
+	assert.Nil(t, health)
+}
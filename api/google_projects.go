@@ -0,0 +1,182 @@
+// Copyright 2019 Booking.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"cloud.google.com/go/securitycenter/apiv1/securitycenterpb"
+	"google.golang.org/api/iterator"
+)
+
+// findings filter and project grouping field of owners findings counts
+const (
+	sccOwnersFilter       = `state="ACTIVE" AND (severity="HIGH" OR severity="CRITICAL")`
+	sccOwnersProjectField = "resource.project_display_name"
+)
+
+// SCCSeverityCounts stores number of active high and critical findings
+type SCCSeverityCounts struct {
+	High     int64
+	Critical int64
+}
+
+// SCCOwnersFindings stores active high and critical findings counts per project and per folder display name,
+// findings of the project are counted in every folder it's nested in
+type SCCOwnersFindings struct {
+	Projects map[string]*SCCSeverityCounts
+	Folders  map[string]*SCCSeverityCounts
+}
+
+// SCCProjectFilter selects projects by display name: project should match Include
+// and should not match Exclude, nil Exclude excludes nothing
+type SCCProjectFilter struct {
+	Include *regexp.Regexp
+	Exclude *regexp.Regexp
+}
+
+// NewSCCProjectFilter returns project filter for given include and exclude regular expressions,
+// empty exclude regex doesn't exclude any project
+func NewSCCProjectFilter(include, exclude string) (*SCCProjectFilter, error) {
+	var f SCCProjectFilter
+	var err error
+	if f.Include, err = regexp.Compile(include); err != nil {
+		return nil, fmt.Errorf("error compiling include regex: %w", err)
+	}
+	if exclude != "" {
+		if f.Exclude, err = regexp.Compile(exclude); err != nil {
+			return nil, fmt.Errorf("error compiling exclude regex: %w", err)
+		}
+	}
+	return &f, nil
+}
+
+// match returns true if project with given display name is selected by the filter
+func (f *SCCProjectFilter) match(project string) bool {
+	return f.Include.MatchString(project) && (f.Exclude == nil || !f.Exclude.MatchString(project))
+}
+
+// GetOwnersFindings returns active high and critical findings counts of given sources per project and folder,
+// counting only findings of projects selected by given filter, along with errors of sources which failed
+// to be queried; sources map is the one returned by GetSourcesByName. Findings are grouped by project,
+// and folders ancestry of every project is taken from single finding of it the first time project is seen
+// and reused afterwards, with folder counts being sum of counts of projects nested in it
+// https://cloud.google.com/security-command-center/docs/reference/rest/v1/organizations.sources.findings/group
+func (s *SCC) GetOwnersFindings(sources map[string]string, filter *SCCProjectFilter) (*SCCOwnersFindings, map[string]error) {
+	result := &SCCOwnersFindings{Projects: map[string]*SCCSeverityCounts{}, Folders: map[string]*SCCSeverityCounts{}}
+	var mu sync.Mutex
+	errs := s.forEachSource(sources, func(ctx context.Context, id, _ string) error {
+		req := &securitycenterpb.GroupFindingsRequest{
+			Parent:  id,
+			Filter:  sccOwnersFilter,
+			GroupBy: sccOwnersProjectField + ",severity",
+		}
+		it := s.api.GroupFindings(ctx, req)
+		counts := map[string]*SCCSeverityCounts{}
+		for {
+			group, err := it.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("findings groups iterator problem: %w", err)
+			}
+			project := group.GetProperties()[sccOwnersProjectField].GetStringValue()
+			if project == "" || !filter.match(project) {
+				continue
+			}
+			switch group.GetProperties()["severity"].GetStringValue() {
+			case securitycenterpb.Finding_CRITICAL.String():
+				countsOf(counts, project).Critical += group.GetCount()
+			case securitycenterpb.Finding_HIGH.String():
+				countsOf(counts, project).High += group.GetCount()
+			}
+		}
+
+		for project := range counts {
+			s.foldersLock.Lock()
+			_, ok := s.projectFolders[project]
+			s.foldersLock.Unlock()
+			if ok {
+				continue
+			}
+			projectFolders, err := s.getProjectFolders(ctx, id, project)
+			if err != nil {
+				return err
+			}
+			s.foldersLock.Lock()
+			if s.projectFolders == nil {
+				s.projectFolders = map[string][]string{}
+			}
+			s.projectFolders[project] = projectFolders
+			s.foldersLock.Unlock()
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		for project, c := range counts {
+			countsOf(result.Projects, project).add(c)
+		}
+		return nil
+	})
+
+	s.foldersLock.Lock()
+	defer s.foldersLock.Unlock()
+	for project, c := range result.Projects {
+		for _, folder := range s.projectFolders[project] {
+			countsOf(result.Folders, folder).add(c)
+		}
+	}
+	return result, errs
+}
+
+// getProjectFolders returns display names of folders given project is nested in,
+// taken from single active high or critical finding of it within given source
+func (s *SCC) getProjectFolders(ctx context.Context, source, project string) ([]string, error) {
+	req := &securitycenterpb.ListFindingsRequest{
+		Parent:   source,
+		Filter:   fmt.Sprintf("%s AND %s=%q", sccOwnersFilter, sccOwnersProjectField, project),
+		PageSize: 1,
+	}
+	finding, err := s.api.ListFindings(ctx, req).Next()
+	if err == iterator.Done {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("project %q findings iterator problem: %w", project, err)
+	}
+	var result []string
+	for _, folder := range finding.GetResource().GetFolders() {
+		result = append(result, folder.GetResourceFolderDisplayName())
+	}
+	return result, nil
+}
+
+// add adds given counts
+func (c *SCCSeverityCounts) add(other *SCCSeverityCounts) {
+	c.High += other.High
+	c.Critical += other.Critical
+}
+
+// countsOf returns counts stored in given map under given key, adding them when absent
+func countsOf(m map[string]*SCCSeverityCounts, key string) *SCCSeverityCounts {
+	if m[key] == nil {
+		m[key] = &SCCSeverityCounts{}
+	}
+	return m[key]
+}
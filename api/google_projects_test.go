@@ -0,0 +1,104 @@
+// Copyright 2019 Booking.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"testing"
+
+	"cloud.google.com/go/securitycenter/apiv1/securitycenterpb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestNewSCCProjectFilter(t *testing.T) {
+	f, err := NewSCCProjectFilter("^prod-", "")
+	assert.NoError(t, err)
+	assert.Nil(t, f.Exclude)
+	assert.True(t, f.match("prod-web"))
+	assert.False(t, f.match("dev-web"))
+
+	f, err = NewSCCProjectFilter(".", "sandbox")
+	assert.NoError(t, err)
+	assert.True(t, f.match("prod-web"))
+	assert.False(t, f.match("prod-sandbox"))
+
+	_, err = NewSCCProjectFilter("bad_regex(", "")
+	assert.EqualError(t, err, "error compiling include regex: error parsing regexp: missing closing ): `bad_regex(`")
+	_, err = NewSCCProjectFilter(".", "bad_regex(")
+	assert.EqualError(t, err, "error compiling exclude regex: error parsing regexp: missing closing ): `bad_regex(`")
+}
+
+func TestSCC_GetOwnersFindings(t *testing.T) {
+	group := func(project, severity string, count int64) *securitycenterpb.GroupResult {
+		return &securitycenterpb.GroupResult{Count: count, Properties: map[string]*structpb.Value{
+			"resource.project_display_name": structpb.NewStringValue(project), "severity": structpb.NewStringValue(severity)}}
+	}
+	finding := func(folders ...string) []*securitycenterpb.ListFindingsResponse_ListFindingsResult {
+		r := &securitycenterpb.ListFindingsResponse_ListFindingsResult{
+			Resource: &securitycenterpb.ListFindingsResponse_ListFindingsResult_Resource{}}
+		for _, f := range folders {
+			r.Resource.Folders = append(r.Resource.Folders, &securitycenterpb.Folder{ResourceFolderDisplayName: f})
+		}
+		return []*securitycenterpb.ListFindingsResponse_ListFindingsResult{r}
+	}
+	const filter = `state="ACTIVE" AND (severity="HIGH" OR severity="CRITICAL")`
+	m := &mockSCC{
+		groups: map[string][]*securitycenterpb.GroupResult{
+			"sources/1": {group("web", "HIGH", 2), group("web", "CRITICAL", 1), group("db", "CRITICAL", 3),
+				// ignored: excluded project and no project
+				group("sandbox", "CRITICAL", 1), group("", "HIGH", 1)},
+			"sources/2": {group("web", "HIGH", 1)},
+		},
+		findings: map[string][]*securitycenterpb.ListFindingsResponse_ListFindingsResult{
+			`sources/1 ` + filter + ` AND resource.project_display_name="web"`: finding("team", "org-unit"),
+			`sources/2 ` + filter + ` AND resource.project_display_name="web"`: finding("team", "org-unit"),
+			`sources/1 ` + filter + ` AND resource.project_display_name="db"`:  finding("org-unit"),
+		},
+	}
+	projectFilter, err := NewSCCProjectFilter(".", "sandbox")
+	assert.NoError(t, err)
+	scc := &SCC{api: m, workers: 2}
+	findings, errs := scc.GetOwnersFindings(map[string]string{"sources/1": "SHA", "sources/2": "ETD"}, projectFilter)
+	assert.Empty(t, errs)
+	assert.Equal(t, &SCCOwnersFindings{
+		Projects: map[string]*SCCSeverityCounts{"web": {High: 3, Critical: 1}, "db": {Critical: 3}},
+		Folders:  map[string]*SCCSeverityCounts{"team": {High: 3, Critical: 1}, "org-unit": {High: 3, Critical: 4}},
+	}, findings)
+
+	// folders of known projects are not looked up again
+	m.findings = nil
+	m.errors = map[string]error{"sources/1 " + filter + ` AND resource.project_display_name="web"`: fmt.Errorf("mock error")}
+	findings, errs = scc.GetOwnersFindings(map[string]string{"sources/1": "SHA", "sources/2": "ETD"}, projectFilter)
+	assert.Empty(t, errs)
+	assert.Equal(t, map[string]*SCCSeverityCounts{"team": {High: 3, Critical: 1}, "org-unit": {High: 3, Critical: 4}},
+		findings.Folders)
+
+	m = &mockSCC{
+		groups: map[string][]*securitycenterpb.GroupResult{"sources/1": {group("web", "HIGH", 1)}},
+		errors: map[string]error{
+			"sources/1 " + filter + ` AND resource.project_display_name="web"`: fmt.Errorf("mock error"),
+			"sources/2": fmt.Errorf("mock error"),
+		},
+	}
+	scc = &SCC{api: m, workers: 2}
+	findings, errs = scc.GetOwnersFindings(map[string]string{"sources/1": "SHA", "sources/2": "ETD"}, projectFilter)
+	assert.Len(t, errs, 2)
+	assert.EqualError(t, errs["SHA"], `project "web" findings iterator problem: mock error`)
+	assert.EqualError(t, errs["ETD"], "findings groups iterator problem: mock error")
+	assert.Equal(t, &SCCOwnersFindings{Projects: map[string]*SCCSeverityCounts{}, Folders: map[string]*SCCSeverityCounts{}}, findings)
+	assert.Equal(t, &securitycenterpb.GroupFindingsRequest{Parent: "sources/2", Filter: filter,
+		GroupBy: "resource.project_display_name,severity"}, m.requests["sources/2"])
+}
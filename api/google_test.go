@@ -15,12 +15,20 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"cloud.google.com/go/securitycenter/apiv1/securitycenterpb"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/iterator"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func TestGetSCCHealthStatus(t *testing.T) {
@@ -79,17 +87,214 @@ func TestGetSCCHealthStatus(t *testing.T) {
 	}
 }
 
-func TestGetSCC_BadEnvFailure(t *testing.T) {
-	x, err := GetSCCLatestEventTime(nil)
+func TestNewSCC_BadEnvFailure(t *testing.T) {
+	x, err := NewSCC()
 	assert.Nil(t, x)
 	assert.Error(t, err, "no authentication present should result in error")
-	y, err := GetSCCSourcesByName("", "")
-	assert.Nil(t, y)
-	assert.Error(t, err, "no authentication present should result in error")
 }
 
-func TestGetSCCSourcesByName_BadRegexp(t *testing.T) {
-	x, err := GetSCCSourcesByName("", "bad_regex(")
-	assert.Nil(t, x)
-	assert.EqualError(t, err, "error compiling nameRegex: error parsing regexp: missing closing ): `bad_regex(`")
+func TestSCC_GetSourcesByName(t *testing.T) {
+	sources := []*securitycenterpb.Source{
+		{Name: "organizations/1/sources/1", DisplayName: "Security Health Analytics"},
+		{Name: "organizations/1/sources/2", DisplayName: "Forseti"},
+	}
+	var testDataset = []struct {
+		regex   string
+		err     error
+		error   string
+		sources map[string]string
+	}{
+		{regex: "bad_regex(", error: "error compiling nameRegex: error parsing regexp: missing closing ): `bad_regex(`"},
+		{regex: ".", err: fmt.Errorf("mock error"), error: "sources iterator problem: mock error"},
+		{regex: ".", sources: map[string]string{
+			"organizations/1/sources/1": "Security Health Analytics", "organizations/1/sources/2": "Forseti"}},
+		{regex: "^Forseti$", sources: map[string]string{"organizations/1/sources/2": "Forseti"}},
+	}
+	for i, x := range testDataset {
+		m := &mockSCC{sources: sources, errors: map[string]error{"organizations/1": x.err}}
+		result, err := (&SCC{api: m}).GetSourcesByName("1", x.regex)
+		if x.error != "" {
+			assert.EqualError(t, err, x.error, "Test case %d error check failed", i)
+		} else {
+			assert.NoError(t, err, "Test case %d error check failed", i)
+		}
+		assert.Equal(t, x.sources, result, "Test case %d sources check failed", i)
+	}
+
+	m := &mockSCC{sources: sources}
+	for _, parent := range []string{"folders/2", "projects/web"} {
+		scc := &SCC{api: m}
+		result, err := scc.GetSourcesByName(parent, "^Forseti$")
+		assert.NoError(t, err)
+		assert.Equal(t, &securitycenterpb.ListSourcesRequest{Parent: parent}, m.requests[parent],
+			"Folder and project parents are used as is")
+		assert.Equal(t, map[string]string{parent + "/sources/2": "Forseti"}, result,
+			"Source is nested under folder or project parent")
+
+		_, errs := scc.GetFindingsCounts(result)
+		assert.Empty(t, errs)
+		assert.Equal(t, &securitycenterpb.GroupFindingsRequest{Parent: parent + "/sources/2", Filter: `state="ACTIVE"`,
+			GroupBy: "category,severity"}, m.requests[parent+"/sources/2"], "Findings are queried within the parent")
+	}
+}
+
+func TestSCC_GetLatestEventTime(t *testing.T) {
+	eventTime := time.Now().Add(-time.Hour)
+	m := &mockSCC{findings: map[string][]*securitycenterpb.ListFindingsResponse_ListFindingsResult{
+		"sources/1": {{Finding: &securitycenterpb.Finding{EventTime: timestamppb.New(eventTime)}}},
+	}, errors: map[string]error{"sources/3": fmt.Errorf("mock error")}}
+	scc := &SCC{api: m, workers: 2}
+	delay, errs := scc.GetLatestEventTime(map[string]string{"sources/1": "SHA", "sources/2": "empty", "sources/3": "broken"})
+	assert.Equal(t, map[string]error{"broken": fmt.Errorf("events iterator problem: %w", fmt.Errorf("mock error"))}, errs,
+		"Failed source error is returned")
+	assert.Len(t, delay, 1, "Source without findings is skipped and failed source doesn't affect others")
+	assert.InDelta(t, time.Hour, delay["SHA"], float64(time.Minute))
+	assert.Equal(t, &securitycenterpb.ListFindingsRequest{Parent: "sources/1", OrderBy: "event_time desc", PageSize: 1},
+		m.requests["sources/1"])
+}
+
+func TestSCC_GetLatestEventTime_DelayQuery(t *testing.T) {
+	now := time.Now()
+	m := &mockSCC{findings: map[string][]*securitycenterpb.ListFindingsResponse_ListFindingsResult{
+		"sources/1": {{Finding: &securitycenterpb.Finding{EventTime: timestamppb.New(now.Add(-time.Hour * 24)),
+			CreateTime: timestamppb.New(now.Add(-time.Hour))}}},
+		"sources/2": {{Finding: &securitycenterpb.Finding{EventTime: timestamppb.New(now)}}},
+	}}
+	scc := &SCC{api: m, workers: 1}
+	assert.EqualError(t, scc.SetDelayQuery(SCCDelayQuery{OrderBy: "update_time"}),
+		`unsupported findings order field "update_time", should be event_time or create_time`)
+	assert.NoError(t, scc.SetDelayQuery(SCCDelayQuery{OrderBy: SCCOrderCreateTime, Filter: `state="ACTIVE"`,
+		SourceFilters: map[string]string{"Forseti": `state="ACTIVE" AND category="custom"`}}))
+
+	delay, errs := scc.GetLatestEventTime(map[string]string{"sources/1": "SHA", "sources/2": "Forseti"})
+	assert.Equal(t, map[string]error{"Forseti": fmt.Errorf("latest finding has no create_time set")}, errs)
+	assert.InDelta(t, time.Hour, delay["SHA"], float64(time.Minute), "Delay is calculated by create_time")
+	assert.Equal(t, &securitycenterpb.ListFindingsRequest{Parent: "sources/1", Filter: `state="ACTIVE"`,
+		OrderBy: "create_time desc", PageSize: 1}, m.requests["sources/1"])
+	assert.Equal(t, &securitycenterpb.ListFindingsRequest{Parent: "sources/2", Filter: `state="ACTIVE" AND category="custom"`,
+		OrderBy: "create_time desc", PageSize: 1}, m.requests["sources/2"], "Source filter overrides default one")
+}
+
+func TestSCC_forEachSource(t *testing.T) {
+	sources := map[string]string{}
+	for i := 0; i < 20; i++ {
+		sources[fmt.Sprintf("sources/%d", i)] = fmt.Sprintf("source %d", i)
+	}
+	var running, maxRunning, calls atomic.Int32
+	scc := &SCC{}
+	scc.SetWorkers(3)
+	errs := scc.forEachSource(sources, func(ctx context.Context, id, name string) error {
+		calls.Add(1)
+		_, ok := ctx.Deadline()
+		assert.True(t, ok, "Every call has timeout")
+		current := running.Add(1)
+		defer running.Add(-1)
+		for {
+			observed := maxRunning.Load()
+			if current <= observed || maxRunning.CompareAndSwap(observed, current) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		if name == "source 5" {
+			return fmt.Errorf("failed %s", id)
+		}
+		return nil
+	})
+	assert.Equal(t, map[string]error{"source 5": fmt.Errorf("failed sources/5")}, errs)
+	assert.Equal(t, int32(20), calls.Load())
+	assert.LessOrEqual(t, maxRunning.Load(), int32(3), "No more than set number of workers run at once")
+
+	scc.SetWorkers(0)
+	assert.Equal(t, 1, scc.workers)
+}
+
+// mockSCC answers SCC API requests with data and errors registered for request parent, recording last request;
+// findings and their errors could be registered for parent and filter separated by space
+type mockSCC struct {
+	mu                  sync.Mutex
+	sources             []*securitycenterpb.Source
+	findings            map[string][]*securitycenterpb.ListFindingsResponse_ListFindingsResult
+	groups              map[string][]*securitycenterpb.GroupResult
+	muteConfigs         []*securitycenterpb.MuteConfig
+	notificationConfigs []*securitycenterpb.NotificationConfig
+	errors              map[string]error
+	requests            map[string]proto.Message
+}
+
+func (m *mockSCC) record(parent string, req proto.Message) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.requests == nil {
+		m.requests = map[string]proto.Message{}
+	}
+	m.requests[parent] = req
+}
+
+func (m *mockSCC) ListSources(_ context.Context, req *securitycenterpb.ListSourcesRequest) sccIterator[*securitycenterpb.Source] {
+	m.record(req.Parent, req)
+	return &mockIterator[*securitycenterpb.Source]{items: m.sources, err: m.errors[req.Parent]}
+}
+
+func (m *mockSCC) ListFindings(_ context.Context, req *securitycenterpb.ListFindingsRequest) sccIterator[*securitycenterpb.ListFindingsResponse_ListFindingsResult] {
+	m.record(req.Parent, req)
+	findings, ok := m.findings[req.Parent+" "+req.Filter]
+	if !ok {
+		findings = m.findings[req.Parent]
+	}
+	err, ok := m.errors[req.Parent+" "+req.Filter]
+	if !ok {
+		err = m.errors[req.Parent]
+	}
+	return &mockIterator[*securitycenterpb.ListFindingsResponse_ListFindingsResult]{items: findings, err: err}
+}
+
+func (m *mockSCC) GroupFindings(_ context.Context, req *securitycenterpb.GroupFindingsRequest) sccIterator[*securitycenterpb.GroupResult] {
+	m.record(req.Parent, req)
+	groups, ok := m.groups[req.Parent+" "+req.Filter]
+	if !ok {
+		groups = m.groups[req.Parent]
+	}
+	err, ok := m.errors[req.Parent+" "+req.Filter]
+	if !ok {
+		err = m.errors[req.Parent]
+	}
+	return &mockIterator[*securitycenterpb.GroupResult]{items: groups, err: err}
+}
+
+func (m *mockSCC) ListMuteConfigs(_ context.Context, req *securitycenterpb.ListMuteConfigsRequest) sccIterator[*securitycenterpb.MuteConfig] {
+	m.record(req.Parent, req)
+	return &mockIterator[*securitycenterpb.MuteConfig]{items: m.muteConfigs, err: m.errors[req.Parent]}
+}
+
+func (m *mockSCC) GroupAssets(_ context.Context, req *securitycenterpb.GroupAssetsRequest) sccIterator[*securitycenterpb.GroupResult] {
+	m.record(req.Parent, req)
+	return &mockIterator[*securitycenterpb.GroupResult]{items: m.groups[req.Parent], err: m.errors[req.Parent]}
+}
+
+func (m *mockSCC) ListNotificationConfigs(_ context.Context, req *securitycenterpb.ListNotificationConfigsRequest) sccIterator[*securitycenterpb.NotificationConfig] {
+	m.record(req.Parent, req)
+	return &mockIterator[*securitycenterpb.NotificationConfig]{items: m.notificationConfigs, err: m.errors[req.Parent]}
+}
+
+func (m *mockSCC) Close() error {
+	return nil
+}
+
+// mockIterator returns given items one by one, or given error if it's set
+type mockIterator[T any] struct {
+	items []T
+	err   error
+}
+
+func (it *mockIterator[T]) Next() (T, error) {
+	var item T
+	if it.err != nil {
+		return item, it.err
+	}
+	if len(it.items) == 0 {
+		return item, iterator.Done
+	}
+	item, it.items = it.items[0], it.items[1:]
+	return item, nil
 }
@@ -0,0 +1,159 @@
+// Copyright 2019 Booking.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"cloud.google.com/go/securitycenter/apiv1/securitycenterpb"
+	securitycenterv2 "cloud.google.com/go/securitycenter/apiv2"
+	securitycenterpbv2 "cloud.google.com/go/securitycenter/apiv2/securitycenterpb"
+	"google.golang.org/api/option"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// SCC API versions
+const (
+	SCCAPIv1 = "v1"
+	SCCAPIv2 = "v2"
+)
+
+// DefaultSCCLocation is SCC v2 API location used for organisations without data residency
+const DefaultSCCLocation = "global"
+
+// sccClientV2 adapts v2 API securitycenter.Client to sccCaller interface: requests are converted from v1
+// with location added to parents of location-aware resources, and responses are converted back to v1 messages
+type sccClientV2 struct {
+	client   *securitycenterv2.Client
+	location string
+}
+
+// NewSCCV2 creates Security Command Center client using v2 API for resources in given location,
+// which is DefaultSCCLocation unless data residency is enabled; application default credentials are used by default.
+// Assets and findings state changes are not available in v2 API, so GetAssetCounts and GetFindingsLifecycle fail
+func NewSCCV2(location string, opts ...option.ClientOption) (*SCC, error) {
+	// context is used only for the client creation and must not be canceled while client is in use
+	client, err := securitycenterv2.NewClient(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("securitycenter.NewClient: %w", err)
+	}
+	return &SCC{api: &sccClientV2{client: client, location: location}, workers: defaultSCCWorkers}, nil
+}
+
+// locationParent returns given v1 parent with location added, parents which already have location are kept as is
+func (c *sccClientV2) locationParent(parent string) string {
+	if strings.Contains(parent, "/locations/") {
+		return parent
+	}
+	return parent + "/locations/" + c.location
+}
+
+func (c *sccClientV2) ListSources(ctx context.Context, req *securitycenterpb.ListSourcesRequest) sccIterator[*securitycenterpb.Source] {
+	// sources are not location-aware
+	it := c.client.ListSources(ctx, &securitycenterpbv2.ListSourcesRequest{Parent: req.Parent, PageSize: req.PageSize})
+	return &convertIterator[*securitycenterpbv2.Source, *securitycenterpb.Source]{it: it, newItem: newProto[securitycenterpb.Source]}
+}
+
+func (c *sccClientV2) ListFindings(ctx context.Context, req *securitycenterpb.ListFindingsRequest) sccIterator[*securitycenterpb.ListFindingsResponse_ListFindingsResult] {
+	if req.CompareDuration != nil || req.ReadTime != nil {
+		return &errIterator[*securitycenterpb.ListFindingsResponse_ListFindingsResult]{
+			err: errors.New("findings state changes are not supported by SCC v2 API")}
+	}
+	it := c.client.ListFindings(ctx, &securitycenterpbv2.ListFindingsRequest{
+		Parent:   c.locationParent(req.Parent),
+		Filter:   req.Filter,
+		OrderBy:  req.OrderBy,
+		PageSize: req.PageSize,
+	})
+	return &convertIterator[*securitycenterpbv2.ListFindingsResponse_ListFindingsResult, *securitycenterpb.ListFindingsResponse_ListFindingsResult]{
+		it: it, newItem: newProto[securitycenterpb.ListFindingsResponse_ListFindingsResult]}
+}
+
+func (c *sccClientV2) GroupFindings(ctx context.Context, req *securitycenterpb.GroupFindingsRequest) sccIterator[*securitycenterpb.GroupResult] {
+	if req.CompareDuration != nil || req.ReadTime != nil {
+		return &errIterator[*securitycenterpb.GroupResult]{err: errors.New("findings state changes are not supported by SCC v2 API")}
+	}
+	it := c.client.GroupFindings(ctx, &securitycenterpbv2.GroupFindingsRequest{
+		Parent:   c.locationParent(req.Parent),
+		Filter:   req.Filter,
+		GroupBy:  req.GroupBy,
+		PageSize: req.PageSize,
+	})
+	return &convertIterator[*securitycenterpbv2.GroupResult, *securitycenterpb.GroupResult]{it: it, newItem: newProto[securitycenterpb.GroupResult]}
+}
+
+func (c *sccClientV2) ListMuteConfigs(ctx context.Context, req *securitycenterpb.ListMuteConfigsRequest) sccIterator[*securitycenterpb.MuteConfig] {
+	it := c.client.ListMuteConfigs(ctx, &securitycenterpbv2.ListMuteConfigsRequest{
+		Parent: c.locationParent(req.Parent), PageSize: req.PageSize})
+	return &convertIterator[*securitycenterpbv2.MuteConfig, *securitycenterpb.MuteConfig]{it: it, newItem: newProto[securitycenterpb.MuteConfig]}
+}
+
+func (c *sccClientV2) GroupAssets(_ context.Context, _ *securitycenterpb.GroupAssetsRequest) sccIterator[*securitycenterpb.GroupResult] {
+	return &errIterator[*securitycenterpb.GroupResult]{err: errors.New("assets are not supported by SCC v2 API")}
+}
+
+func (c *sccClientV2) ListNotificationConfigs(ctx context.Context, req *securitycenterpb.ListNotificationConfigsRequest) sccIterator[*securitycenterpb.NotificationConfig] {
+	it := c.client.ListNotificationConfigs(ctx, &securitycenterpbv2.ListNotificationConfigsRequest{
+		Parent: c.locationParent(req.Parent), PageSize: req.PageSize})
+	return &convertIterator[*securitycenterpbv2.NotificationConfig, *securitycenterpb.NotificationConfig]{
+		it: it, newItem: newProto[securitycenterpb.NotificationConfig]}
+}
+
+func (c *sccClientV2) Close() error {
+	return c.client.Close()
+}
+
+// newProto returns new empty message of type T
+func newProto[T any]() *T {
+	return new(T)
+}
+
+// convertIterator returns items of v2 API iterator converted to v1 API messages,
+// fields unknown to v1 API are dropped
+type convertIterator[S proto.Message, T proto.Message] struct {
+	it      sccIterator[S]
+	newItem func() T
+}
+
+func (c *convertIterator[S, T]) Next() (T, error) {
+	var empty T
+	item, err := c.it.Next()
+	if err != nil {
+		return empty, err
+	}
+	data, err := protojson.Marshal(item)
+	if err != nil {
+		return empty, fmt.Errorf("error marshaling v2 API response: %w", err)
+	}
+	result := c.newItem()
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(data, result); err != nil {
+		return empty, fmt.Errorf("error converting v2 API response: %w", err)
+	}
+	return result, nil
+}
+
+// errIterator returns given error for operations not supported by the API
+type errIterator[T any] struct {
+	err error
+}
+
+func (e *errIterator[T]) Next() (T, error) {
+	var item T
+	return item, e.err
+}
@@ -0,0 +1,154 @@
+// Copyright 2019 Booking.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	securitycenterpbv2 "cloud.google.com/go/securitycenter/apiv2/securitycenterpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// fakeSCCServerV2 is SCC v2 API gRPC server answering with registered data and recording request parents by method
+type fakeSCCServerV2 struct {
+	securitycenterpbv2.UnimplementedSecurityCenterServer
+	sources             []*securitycenterpbv2.Source
+	findings            []*securitycenterpbv2.ListFindingsResponse_ListFindingsResult
+	groups              []*securitycenterpbv2.GroupResult
+	muteConfigs         []*securitycenterpbv2.MuteConfig
+	notificationConfigs []*securitycenterpbv2.NotificationConfig
+
+	mu      sync.Mutex
+	parents map[string][]string
+}
+
+func (f *fakeSCCServerV2) record(method, parent string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.parents[method] = append(f.parents[method], parent)
+}
+
+func (f *fakeSCCServerV2) ListSources(_ context.Context, req *securitycenterpbv2.ListSourcesRequest) (*securitycenterpbv2.ListSourcesResponse, error) {
+	f.record("ListSources", req.Parent)
+	return &securitycenterpbv2.ListSourcesResponse{Sources: f.sources}, nil
+}
+
+func (f *fakeSCCServerV2) ListFindings(_ context.Context, req *securitycenterpbv2.ListFindingsRequest) (*securitycenterpbv2.ListFindingsResponse, error) {
+	f.record("ListFindings", req.Parent)
+	return &securitycenterpbv2.ListFindingsResponse{ListFindingsResults: f.findings}, nil
+}
+
+func (f *fakeSCCServerV2) GroupFindings(_ context.Context, req *securitycenterpbv2.GroupFindingsRequest) (*securitycenterpbv2.GroupFindingsResponse, error) {
+	f.record("GroupFindings", req.Parent)
+	return &securitycenterpbv2.GroupFindingsResponse{GroupByResults: f.groups}, nil
+}
+
+func (f *fakeSCCServerV2) ListMuteConfigs(_ context.Context, req *securitycenterpbv2.ListMuteConfigsRequest) (*securitycenterpbv2.ListMuteConfigsResponse, error) {
+	f.record("ListMuteConfigs", req.Parent)
+	return &securitycenterpbv2.ListMuteConfigsResponse{MuteConfigs: f.muteConfigs}, nil
+}
+
+func (f *fakeSCCServerV2) ListNotificationConfigs(_ context.Context, req *securitycenterpbv2.ListNotificationConfigsRequest) (*securitycenterpbv2.ListNotificationConfigsResponse, error) {
+	f.record("ListNotificationConfigs", req.Parent)
+	return &securitycenterpbv2.ListNotificationConfigsResponse{NotificationConfigs: f.notificationConfigs}, nil
+}
+
+// newFakeSCCV2 starts given fake SCC v2 API server on local port and returns SCC client connected to it
+func newFakeSCCV2(t *testing.T, f *fakeSCCServerV2) *SCC {
+	f.parents = map[string][]string{}
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	securitycenterpbv2.RegisterSecurityCenterServer(server, f)
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+
+	scc, err := NewSCCV2(DefaultSCCLocation, option.WithEndpoint(lis.Addr().String()), option.WithoutAuthentication(),
+		option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = scc.Close() })
+	return scc
+}
+
+func TestSCCV2(t *testing.T) {
+	updated := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	f := &fakeSCCServerV2{
+		sources: []*securitycenterpbv2.Source{{Name: "organizations/1/sources/2", DisplayName: "SHA"}},
+		findings: []*securitycenterpbv2.ListFindingsResponse_ListFindingsResult{{Finding: &securitycenterpbv2.Finding{
+			Name:          "organizations/1/sources/2/locations/global/findings/f1",
+			Category:      "OPEN_FIREWALL",
+			EventTime:     timestamppb.New(time.Now().Add(-time.Hour)),
+			MuteInitiator: "Muted by mute config: organizations/1/locations/global/muteConfigs/dev",
+		}}},
+		groups: []*securitycenterpbv2.GroupResult{{Count: 3, Properties: map[string]*structpb.Value{
+			"category": structpb.NewStringValue("OPEN_FIREWALL"), "severity": structpb.NewStringValue("HIGH")}}},
+		muteConfigs: []*securitycenterpbv2.MuteConfig{{Name: "organizations/1/locations/global/muteConfigs/dev",
+			UpdateTime: timestamppb.New(updated), Type: securitycenterpbv2.MuteConfig_STATIC}},
+		notificationConfigs: []*securitycenterpbv2.NotificationConfig{{
+			Name:        "organizations/1/locations/global/notificationConfigs/siem",
+			PubsubTopic: "projects/siem/topics/scc",
+			NotifyConfig: &securitycenterpbv2.NotificationConfig_StreamingConfig_{
+				StreamingConfig: &securitycenterpbv2.NotificationConfig_StreamingConfig{Filter: `state="ACTIVE"`}}}},
+	}
+	scc := newFakeSCCV2(t, f)
+
+	sources, err := scc.GetSourcesByName("1", ".")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"organizations/1/sources/2": "SHA"}, sources)
+
+	delay, errs := scc.GetLatestEventTime(sources)
+	assert.Empty(t, errs)
+	assert.InDelta(t, time.Hour, delay["SHA"], float64(time.Minute))
+
+	counts, errs := scc.GetFindingsCounts(sources)
+	assert.Empty(t, errs)
+	assert.Equal(t, []SCCFindingsCount{{Source: "SHA", Category: "OPEN_FIREWALL", Severity: "high", Count: 3}}, counts)
+
+	mute, errs, err := scc.GetMuteInfo("1", sources)
+	assert.NoError(t, err)
+	assert.Empty(t, errs)
+	assert.Equal(t, &SCCMuteInfo{MutedByCategory: map[string]int64{"OPEN_FIREWALL": 3}, MutedByConfig: map[string]int64{"dev": 3},
+		Configs: []SCCMuteConfig{{ID: "dev", UpdateTime: updated}}}, mute)
+
+	health, err := scc.GetNotificationsHealth("1", time.Hour*2)
+	assert.NoError(t, err)
+	require.Len(t, health, 1)
+	assert.True(t, health[0].Healthy)
+	assert.Equal(t, "siem", health[0].ID)
+
+	_, err = scc.GetAssetCounts("1")
+	assert.EqualError(t, err, "assets groups iterator problem: assets are not supported by SCC v2 API")
+	_, errs = scc.GetFindingsLifecycle(sources, time.Hour)
+	assert.EqualError(t, errs["SHA"], "created findings groups iterator problem: findings state changes are not supported by SCC v2 API")
+
+	assert.Equal(t, map[string][]string{
+		"ListSources":  {"organizations/1"},
+		"ListFindings": {"organizations/1/sources/2/locations/global", "organizations/1/sources/-/locations/global"},
+		"GroupFindings": {"organizations/1/sources/2/locations/global", "organizations/1/sources/2/locations/global",
+			"organizations/1/sources/2/locations/global"},
+		"ListMuteConfigs":         {"organizations/1/locations/global"},
+		"ListNotificationConfigs": {"organizations/1/locations/global"},
+	}, f.parents, "Location is added to parents of location-aware resources only")
+}
@@ -16,15 +16,35 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
+	"net/http"
+	"time"
 
-	"github.com/paskal/go-prisma"
+	"golang.org/x/time/rate"
+)
+
+// Prisma API health check error classes
+const (
+	HealthErrorAuth      = "auth"
+	HealthErrorRateLimit = "rate_limit"
+	HealthErrorTimeout   = "timeout"
+	HealthErrorServer    = "server_error"
+	HealthErrorClient    = "client_error"
+	HealthErrorNetwork   = "network"
+	// request or response problem other than listed above, like malformed response
+	HealthErrorOther = "other"
 )
 
 // Prisma contain credentials for API access
 type Prisma struct {
-	api apiCaller
+	api         apiCaller
+	accessKeyID string
+	// cloud accounts onboarding status counts by account ID and time they are reused for
+	accountStatuses      map[string]accountStatusCounts
+	accountStatusRefresh time.Duration
 }
 
 type apiCaller interface {
@@ -47,13 +67,50 @@ type CompliancePosture struct {
 	ComplianceDetails []ComplianceInfo `json:"complianceDetails"`
 }
 
+// APIHealth stores Prisma API health check result
+type APIHealth struct {
+	Latency time.Duration
+	// zero if no response was received
+	StatusCode int
+	// one of HealthError* constants, empty for successful check
+	ErrorClass string
+}
+
 // NewPrisma returns new Prisma client
 func NewPrisma(username, password, apiURL string) *Prisma {
-	p := Prisma{}
-	p.api = prisma.NewClient(username, password, apiURL)
+	p := Prisma{accessKeyID: username}
+	p.api = newRetryCaller(newPrismaClient(username, password, apiURL))
 	return &p
 }
 
+// SetRetryPolicy sets maximum number of retries of single API call and API requests rate limit,
+// both apply to this client only; non-positive requestsPerSecond disables rate limiting.
+// It must be called before client is used
+func (p *Prisma) SetRetryPolicy(maxRetries int, requestsPerSecond float64) {
+	if r, ok := p.api.(*retryCaller); ok {
+		r.maxRetries = maxRetries
+		limit := rate.Limit(requestsPerSecond)
+		if requestsPerSecond <= 0 {
+			limit = rate.Inf
+		}
+		r.limiter = rate.NewLimiter(limit, 1)
+	}
+}
+
+// SetAccountStatusRefresh sets time cloud account onboarding status is reused for before being requested again,
+// zero means status is requested on every GatherCloudAccountsInfo call
+func (p *Prisma) SetAccountStatusRefresh(refresh time.Duration) {
+	p.accountStatusRefresh = refresh
+}
+
+// GetCallStats returns API calls, retries, rate limited responses and failed calls count since client creation
+func (p *Prisma) GetCallStats() CallStats {
+	if r, ok := p.api.(*retryCaller); ok {
+		return r.stats()
+	}
+	return CallStats{}
+}
+
 // GatherComplianceInfo get assets compliance information for last day
 // https://api.docs.prismacloud.io/reference#compliance-posture
 func (p *Prisma) GatherComplianceInfo() ([]ComplianceInfo, error) {
@@ -70,11 +127,70 @@ func (p *Prisma) GatherComplianceInfo() ([]ComplianceInfo, error) {
 	return posture.ComplianceDetails, nil
 }
 
-// GetAPIHealthStatus gets Prisma API health information and returns 1 on healthy response, 0 otherwise
+// GetAPIHealthStatus gets Prisma API health information and returns 0 in case of SLA breach, 1 otherwise
 // https://api.docs.prismacloud.io/reference#health-check
 func (p *Prisma) GetAPIHealthStatus() int {
-	if _, err := p.api.Call("GET", "/check", nil); err != nil {
+	if p.GetAPIHealth().SLABreach() {
 		return 0
 	}
 	return 1
 }
+
+// GetAPIHealth gets Prisma API health information along with response latency,
+// which includes login time when token renewal was due; check is neither retried
+// nor rate limited, so latency doesn't include waiting for other calls
+// https://api.docs.prismacloud.io/reference#health-check
+func (p *Prisma) GetAPIHealth() APIHealth {
+	caller := p.api
+	if r, ok := p.api.(*retryCaller); ok {
+		caller = r.api
+	}
+	start := time.Now()
+	_, err := caller.Call("GET", "/check", nil)
+	h := APIHealth{Latency: time.Since(start), StatusCode: http.StatusOK}
+	if err != nil {
+		h.ErrorClass, h.StatusCode = classifyAPIError(err)
+	}
+	return h
+}
+
+// SLABreach returns true if health check failed on Prisma side, failures caused
+// by client like authentication problem or exceeded rate limit are not SLA breach
+func (h APIHealth) SLABreach() bool {
+	switch h.ErrorClass {
+	case HealthErrorTimeout, HealthErrorServer, HealthErrorNetwork, HealthErrorOther:
+		return true
+	}
+	return false
+}
+
+// HealthErrorClasses returns all possible APIHealth error classes
+func HealthErrorClasses() []string {
+	return []string{HealthErrorAuth, HealthErrorRateLimit, HealthErrorTimeout,
+		HealthErrorServer, HealthErrorClient, HealthErrorNetwork, HealthErrorOther}
+}
+
+// classifyAPIError returns class and HTTP status code of given API call error
+func classifyAPIError(err error) (string, int) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden:
+			return HealthErrorAuth, apiErr.StatusCode
+		case apiErr.StatusCode == http.StatusTooManyRequests:
+			return HealthErrorRateLimit, apiErr.StatusCode
+		case apiErr.StatusCode >= http.StatusInternalServerError:
+			return HealthErrorServer, apiErr.StatusCode
+		default:
+			return HealthErrorClient, apiErr.StatusCode
+		}
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return HealthErrorTimeout, 0
+		}
+		return HealthErrorNetwork, 0
+	}
+	return HealthErrorOther, 0
+}
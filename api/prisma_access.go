@@ -0,0 +1,95 @@
+// Copyright 2019 Booking.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// AccessKeyInfo stores expiration information of single Prisma access key
+type AccessKeyInfo struct {
+	ID     string
+	Name   string
+	Status string
+	// key used by this client for API access
+	Own bool
+	// time left until key expiration, negative for already expired keys, not set for keys without expiration
+	ExpiresIn *time.Duration
+}
+
+// AccessHygieneInfo stores Prisma access keys and users information
+type AccessHygieneInfo struct {
+	KeysByStatus      map[string]int
+	Keys              []AccessKeyInfo
+	ActiveUsersByRole map[string]int
+}
+
+// accessKey stores fields of single Prisma access key entry
+type accessKey struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	ExpiresOn int64  `json:"expiresOn"`
+}
+
+// user stores fields of single Prisma user entry
+type user struct {
+	Email    string `json:"email"`
+	RoleName string `json:"roleName"`
+	Enabled  bool   `json:"enabled"`
+}
+
+// GatherAccessHygieneInfo returns access keys count by status and expiration time of every key,
+// including the one used by this client, and active (enabled) users count by role
+// https://pan.dev/prisma-cloud/api/cspm/get-access-keys/
+// https://pan.dev/prisma-cloud/api/cspm/get-users/
+func (p *Prisma) GatherAccessHygieneInfo() (*AccessHygieneInfo, error) {
+	data, err := p.api.Call("GET", "/access_keys", nil)
+	if err != nil {
+		return nil, fmt.Errorf("error requesting access keys information: %w", err)
+	}
+	var keys []accessKey
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("error unmarshaling access keys information: %w", err)
+	}
+
+	data, err = p.api.Call("GET", "/user", nil)
+	if err != nil {
+		return nil, fmt.Errorf("error requesting users information: %w", err)
+	}
+	var users []user
+	if err := json.Unmarshal(data, &users); err != nil {
+		return nil, fmt.Errorf("error unmarshaling users information: %w", err)
+	}
+
+	info := &AccessHygieneInfo{KeysByStatus: map[string]int{}, Keys: []AccessKeyInfo{}, ActiveUsersByRole: map[string]int{}}
+	for _, k := range keys {
+		info.KeysByStatus[k.Status]++
+		keyInfo := AccessKeyInfo{ID: k.ID, Name: k.Name, Status: k.Status, Own: k.ID == p.accessKeyID}
+		if k.ExpiresOn > 0 {
+			expiresIn := time.Until(time.UnixMilli(k.ExpiresOn))
+			keyInfo.ExpiresIn = &expiresIn
+		}
+		info.Keys = append(info.Keys, keyInfo)
+	}
+	for _, u := range users {
+		if u.Enabled {
+			info.ActiveUsersByRole[u.RoleName]++
+		}
+	}
+	return info, nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2019 Booking.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrisma_GatherAccessHygieneInfo(t *testing.T) {
+	inTenDays := time.Now().Add(time.Hour * 24 * 10).UnixMilli()
+	tenDays := time.Hour * 24 * 10
+	var testAPIRequestsDataset = []struct {
+		keys  mockResponse
+		users mockResponse
+		error string
+		info  *AccessHygieneInfo
+	}{
+		{keys: mockResponse{err: fmt.Errorf("mock error")},
+			error: "error requesting access keys information: mock error"},
+		{keys: mockResponse{answer: []byte("not_json")},
+			error: "error unmarshaling access keys information: invalid character 'o' in literal null (expecting 'u')"},
+		{keys: mockResponse{answer: []byte(`[]`)}, users: mockResponse{err: fmt.Errorf("mock error")},
+			error: "error requesting users information: mock error"},
+		{keys: mockResponse{answer: []byte(`[]`)}, users: mockResponse{answer: []byte("not_json")},
+			error: "error unmarshaling users information: invalid character 'o' in literal null (expecting 'u')"},
+		{keys: mockResponse{answer: []byte(fmt.Sprintf(`[
+{"id":"own_key","name":"metrics","status":"active","expiresOn":%d},
+{"id":"other_key","name":"ci","status":"expired","expiresOn":0}]`, inTenDays))},
+			users: mockResponse{answer: []byte(`[
+{"email":"a@example.com","roleName":"System Admin","enabled":true},
+{"email":"b@example.com","roleName":"System Admin","enabled":false},
+{"email":"c@example.com","roleName":"Account Group Read Only","enabled":true}]`)},
+			info: &AccessHygieneInfo{
+				KeysByStatus: map[string]int{"active": 1, "expired": 1},
+				Keys: []AccessKeyInfo{
+					{ID: "own_key", Name: "metrics", Status: "active", Own: true, ExpiresIn: &tenDays},
+					{ID: "other_key", Name: "ci", Status: "expired"}},
+				ActiveUsersByRole: map[string]int{"System Admin": 1, "Account Group Read Only": 1}}},
+	}
+
+	// start tests
+	p := &Prisma{accessKeyID: "own_key"}
+
+	for i, x := range testAPIRequestsDataset {
+		p.api = &mockRoutes{t: t, routes: map[string]mockResponse{"GET /access_keys": x.keys, "GET /user": x.users}}
+		info, err := p.GatherAccessHygieneInfo()
+		if x.error != "" {
+			assert.EqualError(t, err, x.error, "Test case %d error check failed", i)
+		} else {
+			assert.NoError(t, err, "Test case %d error check failed", i)
+			// expiration is calculated against current time, round it to compare
+			for _, k := range info.Keys {
+				if k.ExpiresIn != nil {
+					*k.ExpiresIn = k.ExpiresIn.Round(time.Minute)
+				}
+			}
+		}
+		assert.Equal(t, x.info, info, "Test case %d access hygiene info check failed", i)
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2019 Booking.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// accountStatusWorkers is number of cloud account status requests sent at once,
+// it's small as all of them share tenant API rate limit
+const accountStatusWorkers = 4
+
+// CloudAccountInfo stores onboarding and ingestion state of single Prisma cloud account
+type CloudAccountInfo struct {
+	ID        string
+	Name      string
+	CloudType string
+	Enabled   bool
+	// time since last successful ingestion, zero if account was never ingested
+	IngestionDelay time.Duration
+	StatusErrors   int
+	StatusWarnings int
+	// error of the onboarding status request, status counts are zero when it's set
+	StatusError error
+}
+
+// cloudAccount stores fields of single Prisma cloud account entry
+type cloudAccount struct {
+	AccountID        string `json:"accountId"`
+	Name             string `json:"name"`
+	CloudType        string `json:"cloudType"`
+	Enabled          bool   `json:"enabled"`
+	IngestionEndTime int64  `json:"ingestionEndTime"`
+	LastFullSnapshot int64  `json:"lastFullSnapshot"`
+}
+
+// accountStatusCounts stores onboarding status counts of single cloud account along with time they were requested
+type accountStatusCounts struct {
+	errors   int
+	warnings int
+	updated  time.Time
+}
+
+// cloudAccountStatus stores single component status of Prisma cloud account onboarding
+type cloudAccountStatus struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// GatherCloudAccountsInfo returns enabled state, ingestion delay and onboarding status
+// of every Prisma cloud account; status is requested only for enabled accounts, up to
+// accountStatusWorkers at once, and is reused until its refresh period set by SetAccountStatusRefresh passes.
+// Failure to get status of one account is reported in its StatusError without affecting others
+// and is not cached, so status is requested again next time
+// https://pan.dev/prisma-cloud/api/cspm/get-cloud-accounts/
+// https://pan.dev/prisma-cloud/api/cspm/get-cloud-account-status/
+func (p *Prisma) GatherCloudAccountsInfo() ([]CloudAccountInfo, error) {
+	data, err := p.api.Call("GET", "/cloud", nil)
+	if err != nil {
+		return nil, fmt.Errorf("error requesting cloud accounts information: %w", err)
+	}
+
+	var accounts []cloudAccount
+	if err := json.Unmarshal(data, &accounts); err != nil {
+		return nil, fmt.Errorf("error unmarshaling cloud accounts information: %w", err)
+	}
+
+	result := make([]CloudAccountInfo, 0, len(accounts))
+	var stale []int
+	for _, account := range accounts {
+		info := CloudAccountInfo{ID: account.AccountID, Name: account.Name, CloudType: account.CloudType, Enabled: account.Enabled}
+		lastIngestion := account.IngestionEndTime
+		if account.LastFullSnapshot > lastIngestion {
+			lastIngestion = account.LastFullSnapshot
+		}
+		if lastIngestion > 0 {
+			info.IngestionDelay = time.Since(time.UnixMilli(lastIngestion))
+		}
+		if account.Enabled {
+			if counts, ok := p.accountStatuses[account.AccountID]; ok && time.Since(counts.updated) < p.accountStatusRefresh {
+				info.StatusErrors, info.StatusWarnings = counts.errors, counts.warnings
+			} else {
+				stale = append(stale, len(result))
+			}
+		}
+		result = append(result, info)
+	}
+	p.refreshCloudAccountsStatus(result, stale)
+	return result, nil
+}
+
+// refreshCloudAccountsStatus requests onboarding status of accounts with given indexes in result, fills their
+// status counts or error and caches successfully requested counts, dropping cached counts of accounts no longer enabled
+func (p *Prisma) refreshCloudAccountsStatus(result []CloudAccountInfo, stale []int) {
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, accountStatusWorkers)
+	for _, i := range stale {
+		wg.Add(1)
+		workers <- struct{}{}
+		go func(info *CloudAccountInfo) {
+			defer func() {
+				<-workers
+				wg.Done()
+			}()
+			statuses, err := p.getCloudAccountStatus(info.ID)
+			info.StatusError = err
+			for _, s := range statuses {
+				switch s.Status {
+				case "error":
+					info.StatusErrors++
+				case "warning":
+					info.StatusWarnings++
+				}
+			}
+		}(&result[i])
+	}
+	wg.Wait()
+
+	statuses := map[string]accountStatusCounts{}
+	for _, info := range result {
+		if counts, ok := p.accountStatuses[info.ID]; ok && info.Enabled {
+			statuses[info.ID] = counts
+		}
+	}
+	now := time.Now()
+	for _, i := range stale {
+		if result[i].StatusError != nil {
+			delete(statuses, result[i].ID)
+			continue
+		}
+		statuses[result[i].ID] = accountStatusCounts{errors: result[i].StatusErrors, warnings: result[i].StatusWarnings, updated: now}
+	}
+	p.accountStatuses = statuses
+}
+
+// getCloudAccountStatus returns onboarding components status of cloud account with given ID
+func (p *Prisma) getCloudAccountStatus(accountID string) ([]cloudAccountStatus, error) {
+	data, err := p.api.Call("GET", "/account/"+url.PathEscape(accountID)+"/config/status", nil)
+	if err != nil {
+		return nil, fmt.Errorf("error requesting cloud account %s status: %w", accountID, err)
+	}
+
+	var statuses []cloudAccountStatus
+	if err := json.Unmarshal(data, &statuses); err != nil {
+		return nil, fmt.Errorf("error unmarshaling cloud account %s status: %w", accountID, err)
+	}
+	return statuses, nil
+}
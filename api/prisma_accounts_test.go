@@ -0,0 +1,135 @@
+// Copyright 2019 Booking.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrisma_GatherCloudAccountsInfo(t *testing.T) {
+	hourAgo := time.Now().Add(-time.Hour).UnixMilli()
+	var testAPIRequestsDataset = []struct {
+		accounts     mockResponse
+		status       mockResponse
+		error        string
+		statusErrors map[string]string
+		info         []CloudAccountInfo
+	}{
+		{accounts: mockResponse{err: fmt.Errorf("mock error")},
+			error: "error requesting cloud accounts information: mock error"},
+		{accounts: mockResponse{answer: []byte("not_json")},
+			error: "error unmarshaling cloud accounts information: invalid character 'o' in literal null (expecting 'u')"},
+		{accounts: mockResponse{answer: []byte(`[{"accountId":"123","name":"prod","cloudType":"aws","enabled":true},
+{"accountId":"456","name":"old","cloudType":"gcp","enabled":false}]`)},
+			status:       mockResponse{err: fmt.Errorf("mock error")},
+			statusErrors: map[string]string{"123": "error requesting cloud account 123 status: mock error"},
+			info: []CloudAccountInfo{
+				{ID: "123", Name: "prod", CloudType: "aws", Enabled: true},
+				{ID: "456", Name: "old", CloudType: "gcp"}}},
+		{accounts: mockResponse{answer: []byte(`[{"accountId":"123","name":"prod","cloudType":"aws","enabled":true}]`)},
+			status: mockResponse{answer: []byte("not_json")},
+			statusErrors: map[string]string{
+				"123": "error unmarshaling cloud account 123 status: invalid character 'o' in literal null (expecting 'u')"},
+			info: []CloudAccountInfo{{ID: "123", Name: "prod", CloudType: "aws", Enabled: true}}},
+		{accounts: mockResponse{answer: []byte(fmt.Sprintf(`[
+{"accountId":"123","name":"prod","cloudType":"aws","enabled":true,"ingestionEndTime":%d},
+{"accountId":"456","name":"old","cloudType":"gcp","enabled":false}]`, hourAgo))},
+			status: mockResponse{answer: []byte(`[
+{"name":"Config","status":"ok"},
+{"name":"Flow Logs","status":"error","message":"no flow logs"},
+{"name":"GuardDuty","status":"warning"}]`)},
+			info: []CloudAccountInfo{
+				{ID: "123", Name: "prod", CloudType: "aws", Enabled: true, IngestionDelay: time.Hour, StatusErrors: 1, StatusWarnings: 1},
+				{ID: "456", Name: "old", CloudType: "gcp"}}},
+	}
+
+	// start tests
+	p := &Prisma{}
+
+	for i, x := range testAPIRequestsDataset {
+		p.api = &mockRoutes{t: t, routes: map[string]mockResponse{
+			"GET /cloud":                     x.accounts,
+			"GET /account/123/config/status": x.status,
+		}}
+		info, err := p.GatherCloudAccountsInfo()
+		if x.error != "" {
+			assert.EqualError(t, err, x.error, "Test case %d error check failed", i)
+		} else {
+			assert.NoError(t, err, "Test case %d error check failed", i)
+		}
+		// ingestion delay is calculated against current time, round it to compare
+		var statusErrors map[string]string
+		for j := range info {
+			info[j].IngestionDelay = info[j].IngestionDelay.Round(time.Minute)
+			if info[j].StatusError != nil {
+				if statusErrors == nil {
+					statusErrors = map[string]string{}
+				}
+				statusErrors[info[j].ID] = info[j].StatusError.Error()
+				info[j].StatusError = nil
+			}
+		}
+		assert.Equal(t, x.statusErrors, statusErrors, "Test case %d status errors check failed", i)
+		assert.Equal(t, x.info, info, "Test case %d cloud accounts info check failed", i)
+	}
+}
+
+func TestPrisma_GatherCloudAccountsInfoStatusRefresh(t *testing.T) {
+	routes := map[string]mockResponse{
+		"GET /cloud": {answer: []byte(`[{"accountId":"123","name":"prod","cloudType":"aws","enabled":true},
+{"accountId":"456","name":"dev","cloudType":"gcp","enabled":true}]`)},
+		"GET /account/123/config/status": {answer: []byte(`[{"name":"Flow Logs","status":"error"}]`)},
+		"GET /account/456/config/status": {err: fmt.Errorf("mock error")},
+	}
+	p := &Prisma{api: &mockRoutes{t: t, routes: routes}}
+	p.SetAccountStatusRefresh(time.Hour)
+
+	info, err := p.GatherCloudAccountsInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, info[0].StatusErrors)
+	assert.EqualError(t, info[1].StatusError, "error requesting cloud account 456 status: mock error")
+
+	// cached status is reused, failed one is requested again
+	routes["GET /account/123/config/status"] = mockResponse{err: fmt.Errorf("not cached")}
+	routes["GET /account/456/config/status"] = mockResponse{answer: []byte(`[{"name":"Config","status":"warning"}]`)}
+	info, err = p.GatherCloudAccountsInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, []CloudAccountInfo{
+		{ID: "123", Name: "prod", CloudType: "aws", Enabled: true, StatusErrors: 1},
+		{ID: "456", Name: "dev", CloudType: "gcp", Enabled: true, StatusWarnings: 1}}, info)
+
+	// status of disabled account is dropped and requested again once it's enabled
+	routes["GET /cloud"] = mockResponse{answer: []byte(`[{"accountId":"123","name":"prod","cloudType":"aws","enabled":false}]`)}
+	_, err = p.GatherCloudAccountsInfo()
+	assert.NoError(t, err)
+	routes["GET /cloud"] = mockResponse{answer: []byte(`[{"accountId":"123","name":"prod","cloudType":"aws","enabled":true}]`)}
+	info, err = p.GatherCloudAccountsInfo()
+	assert.NoError(t, err)
+	assert.EqualError(t, info[0].StatusError, "error requesting cloud account 123 status: not cached")
+
+	// zero refresh requests status every time
+	p.SetAccountStatusRefresh(0)
+	routes["GET /account/123/config/status"] = mockResponse{answer: []byte(`[]`)}
+	_, err = p.GatherCloudAccountsInfo()
+	assert.NoError(t, err)
+	routes["GET /account/123/config/status"] = mockResponse{answer: []byte(`[{"name":"Config","status":"warning"}]`)}
+	info, err = p.GatherCloudAccountsInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, info[0].StatusWarnings)
+}
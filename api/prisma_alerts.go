@@ -0,0 +1,167 @@
+// Copyright 2019 Booking.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"google.golang.org/api/iterator"
+)
+
+// resolved alerts are searched by their raise time, so the window is extended by that many days
+// to catch alerts which were raised long before the window but resolved within it
+const alertsResolvedLookbackDays = 30
+
+const day = time.Hour * 24
+
+// AlertAgingInfo stores open alerts age distribution and resolution time statistics for single severity
+type AlertAgingInfo struct {
+	Severity          string
+	OpenLess1Day      int
+	Open1To7Days      int
+	Open7To30Days     int
+	OpenOver30Days    int
+	ResolvedCount     int
+	MeanTimeToResolve time.Duration
+	P50TimeToResolve  time.Duration
+	P90TimeToResolve  time.Duration
+	P99TimeToResolve  time.Duration
+}
+
+// alert stores fields of single Prisma alert required for aging calculation
+type alert struct {
+	ID          string `json:"id"`
+	Status      string `json:"status"`
+	AlertTime   int64  `json:"alertTime"`
+	LastUpdated int64  `json:"lastUpdated"`
+	Policy      struct {
+		Severity string `json:"severity"`
+	} `json:"policy"`
+}
+
+// GatherAlertAgingInfo returns open alerts age distribution and time to resolve statistics
+// for alerts resolved within given window, per policy severity; alert last update time is used as its resolve time
+// https://pan.dev/prisma-cloud/api/cspm/get-alerts-v-2/
+func (p *Prisma) GatherAlertAgingInfo(window time.Duration) ([]AlertAgingInfo, error) {
+	aging := newAlertAging(time.Now(), window)
+	if err := p.walkAlerts("open", "timeType=to_now&timeUnit=epoch", aging.addOpen); err != nil {
+		return nil, err
+	}
+	if err := p.walkAlerts("resolved",
+		fmt.Sprintf("timeType=relative&timeAmount=%d&timeUnit=day", resolvedLookbackDays(window)), aging.addResolved); err != nil {
+		return nil, err
+	}
+	return aging.result(), nil
+}
+
+// resolvedLookbackDays returns number of days to search resolved alerts raised within, for given window
+func resolvedLookbackDays(window time.Duration) int {
+	return int((window+day-1)/day) + alertsResolvedLookbackDays
+}
+
+// walkAlerts calls fn for every alert with given status, limited by given time range query parameters
+func (p *Prisma) walkAlerts(status, timeRange string, fn func(alert)) error {
+	it := newPager[alert](p.api,
+		getPageRequest(fmt.Sprintf("/v2/alert?%s&alert.status=%s&detailed=false", timeRange, status)), 0, 0)
+	for {
+		a, err := it.Next()
+		if err == iterator.Done {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error requesting %s alerts: %w", status, err)
+		}
+		fn(a)
+	}
+}
+
+// alertAging aggregates alerts by severity, counting only
+// resolved alerts whose last update happened within window before now
+type alertAging struct {
+	now          time.Time
+	window       time.Duration
+	bySeverity   map[string]*AlertAgingInfo
+	resolveTimes map[string][]time.Duration
+}
+
+func newAlertAging(now time.Time, window time.Duration) *alertAging {
+	return &alertAging{now: now, window: window,
+		bySeverity: map[string]*AlertAgingInfo{}, resolveTimes: map[string][]time.Duration{}}
+}
+
+func (g *alertAging) get(severity string) *AlertAgingInfo {
+	if _, ok := g.bySeverity[severity]; !ok {
+		g.bySeverity[severity] = &AlertAgingInfo{Severity: severity}
+	}
+	return g.bySeverity[severity]
+}
+
+func (g *alertAging) addOpen(a alert) {
+	info := g.get(a.Policy.Severity)
+	switch age := g.now.Sub(time.UnixMilli(a.AlertTime)); {
+	case age < day:
+		info.OpenLess1Day++
+	case age < day*7:
+		info.Open1To7Days++
+	case age < day*30:
+		info.Open7To30Days++
+	default:
+		info.OpenOver30Days++
+	}
+}
+
+func (g *alertAging) addResolved(a alert) {
+	resolvedAt := time.UnixMilli(a.LastUpdated)
+	if g.now.Sub(resolvedAt) > g.window {
+		return
+	}
+	g.get(a.Policy.Severity)
+	g.resolveTimes[a.Policy.Severity] = append(g.resolveTimes[a.Policy.Severity], resolvedAt.Sub(time.UnixMilli(a.AlertTime)))
+}
+
+// result returns aggregated information sorted by severity
+func (g *alertAging) result() []AlertAgingInfo {
+	for severity, durations := range g.resolveTimes {
+		info := g.bySeverity[severity]
+		sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+		var total time.Duration
+		for _, d := range durations {
+			total += d
+		}
+		info.ResolvedCount = len(durations)
+		info.MeanTimeToResolve = total / time.Duration(len(durations))
+		info.P50TimeToResolve = percentile(durations, 50)
+		info.P90TimeToResolve = percentile(durations, 90)
+		info.P99TimeToResolve = percentile(durations, 99)
+	}
+
+	result := make([]AlertAgingInfo, 0, len(g.bySeverity))
+	for _, info := range g.bySeverity {
+		result = append(result, *info)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Severity < result[j].Severity })
+	return result
+}
+
+// percentile returns nearest-rank percentile p of given non-empty sorted durations
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
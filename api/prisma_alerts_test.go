@@ -0,0 +1,111 @@
+// Copyright 2019 Booking.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	openAlertsURL     = "GET /v2/alert?timeType=to_now&timeUnit=epoch&alert.status=open&detailed=false&limit=1000"
+	resolvedAlertsURL = "GET /v2/alert?timeType=relative&timeAmount=31&timeUnit=day&alert.status=resolved&detailed=false&limit=1000"
+)
+
+func TestPrisma_GatherAlertAgingInfo(t *testing.T) {
+	now := time.Now().UnixMilli()
+	var testAPIRequestsDataset = []struct {
+		open     mockResponse
+		resolved mockResponse
+		error    string
+		info     []AlertAgingInfo
+	}{
+		{open: mockResponse{err: fmt.Errorf("mock error")},
+			error: "error requesting open alerts: mock error"},
+		{open: mockResponse{answer: []byte("not_json")},
+			error: "error requesting open alerts: error unmarshaling page 1: invalid character 'o' in literal null (expecting 'u')"},
+		{open: mockResponse{answer: []byte(`{"items":[]}`)}, resolved: mockResponse{err: fmt.Errorf("mock error")},
+			error: "error requesting resolved alerts: mock error"},
+		{open: mockResponse{answer: []byte(`{"items":[]}`)}, resolved: mockResponse{answer: []byte(`{"items":[]}`)},
+			info: []AlertAgingInfo{}},
+		{open: mockResponse{answer: []byte(fmt.Sprintf(`{"items":[{"id":"P-1","status":"open","alertTime":%d,"policy":{"severity":"high"}}]}`,
+			now-time.Hour.Milliseconds()))},
+			resolved: mockResponse{answer: []byte(fmt.Sprintf(`{"items":[{"id":"P-2","status":"resolved","alertTime":%d,"lastUpdated":%d,"policy":{"severity":"low"}}]}`,
+				now-time.Hour.Milliseconds()*3, now-time.Hour.Milliseconds()))},
+			info: []AlertAgingInfo{
+				{Severity: "high", OpenLess1Day: 1},
+				{Severity: "low", ResolvedCount: 1, MeanTimeToResolve: time.Hour * 2,
+					P50TimeToResolve: time.Hour * 2, P90TimeToResolve: time.Hour * 2, P99TimeToResolve: time.Hour * 2}}},
+	}
+
+	// start tests
+	p := &Prisma{}
+
+	for i, x := range testAPIRequestsDataset {
+		p.api = &mockRoutes{t: t, routes: map[string]mockResponse{openAlertsURL: x.open, resolvedAlertsURL: x.resolved}}
+		info, err := p.GatherAlertAgingInfo(time.Hour * 24)
+		if x.error != "" {
+			assert.EqualError(t, err, x.error, "Test case %d error check failed", i)
+		} else {
+			assert.NoError(t, err, "Test case %d error check failed", i)
+		}
+		assert.Equal(t, x.info, info, "Test case %d alert aging info check failed", i)
+	}
+}
+
+func TestResolvedLookbackDays(t *testing.T) {
+	assert.Equal(t, 31, resolvedLookbackDays(time.Hour))
+	assert.Equal(t, 31, resolvedLookbackDays(day))
+	assert.Equal(t, 120, resolvedLookbackDays(day*90))
+}
+
+func TestAlertAging(t *testing.T) {
+	now := time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC)
+	openedAgo := func(d time.Duration) alert {
+		a := alert{AlertTime: now.Add(-d).UnixMilli()}
+		a.Policy.Severity = "medium"
+		return a
+	}
+	resolvedAgo := func(raised, resolved time.Duration) alert {
+		a := openedAgo(raised)
+		a.LastUpdated = now.Add(-resolved).UnixMilli()
+		return a
+	}
+	aging := newAlertAging(now, day)
+	for _, a := range []alert{openedAgo(time.Hour), openedAgo(day * 2), openedAgo(day * 8), openedAgo(day * 10), openedAgo(day * 40)} {
+		aging.addOpen(a)
+	}
+	for i := 1; i <= 10; i++ {
+		aging.addResolved(resolvedAgo(time.Hour*time.Duration(i+1), time.Hour))
+	}
+	// resolved outside of window, ignored
+	aging.addResolved(resolvedAgo(day*5, day*2))
+
+	assert.Equal(t, []AlertAgingInfo{{
+		Severity:          "medium",
+		OpenLess1Day:      1,
+		Open1To7Days:      1,
+		Open7To30Days:     2,
+		OpenOver30Days:    1,
+		ResolvedCount:     10,
+		MeanTimeToResolve: time.Hour*5 + time.Minute*30,
+		P50TimeToResolve:  time.Hour * 5,
+		P90TimeToResolve:  time.Hour * 9,
+		P99TimeToResolve:  time.Hour * 10,
+	}}, aging.result())
+}
@@ -0,0 +1,201 @@
+// Copyright 2019 Booking.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Prisma token is valid for 10 minutes, renew it beforehand
+const prismaRenewTimeout = time.Minute * 3
+
+const prismaHTTPTimeout = time.Second * 5
+
+// APIError is returned on non-200 API response
+type APIError struct {
+	StatusCode int
+	Status     string
+	Body       []byte
+	// time to wait before retrying the request as set by server in Retry-After header, zero if absent
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s, response body: %q", e.Status, e.Body)
+}
+
+// tokenClient calls Prisma or Compute console API, obtaining and renewing auth token when needed,
+// both APIs have the same login scheme and differ only in endpoints and token header.
+// It replaces github.com/paskal/go-prisma client, which returns non-200 responses
+// as plain text errors and so doesn't allow to tell auth failure and rate limiting
+// from Prisma side problems, and doesn't expose Retry-After response header
+type tokenClient struct {
+	username   string
+	password   string
+	apiURL     string
+	httpClient http.Client
+	loginPath  string
+	// path of token extension endpoint, token is obtained by new login when it's empty
+	extendPath   string
+	renewTimeout time.Duration
+	// request header carrying token, and token value prefix in it
+	authHeader string
+	authPrefix string
+
+	tokenLock      sync.Mutex
+	token          string
+	tokenRenewTime time.Time
+}
+
+func newPrismaClient(username, password, apiURL string) *tokenClient {
+	return &tokenClient{username: username, password: password, apiURL: apiURL,
+		httpClient: http.Client{Timeout: prismaHTTPTimeout}, loginPath: "/login", extendPath: "/auth_token/extend",
+		renewTimeout: prismaRenewTimeout, authHeader: "x-redlock-auth"}
+}
+
+// Call makes request to API, authenticating beforehand if token is absent or about to expire;
+// request rejected as unauthorized is repeated once after new login, as token might be revoked on Prisma side
+func (c *tokenClient) Call(method, url string, body io.Reader) ([]byte, error) {
+	// body is read in advance to be re-sent after new login
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = io.ReadAll(body); err != nil {
+			return nil, fmt.Errorf("error reading request body: %w", err)
+		}
+	}
+	token, err := c.getToken("")
+	if err != nil {
+		return nil, err
+	}
+	data, err := c.callWithToken(method, url, token, bytesReader(payload))
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		return data, err
+	}
+	if token, err = c.getToken(token); err != nil {
+		return nil, err
+	}
+	return c.callWithToken(method, url, token, bytesReader(payload))
+}
+
+// getToken returns current token, authenticating if it's absent, about to expire
+// or equal to rejected one, unless it was already replaced by concurrent call
+func (c *tokenClient) getToken(rejected string) (string, error) {
+	c.tokenLock.Lock()
+	defer c.tokenLock.Unlock()
+	if rejected != "" && c.token == rejected {
+		c.token = ""
+	}
+	if c.token == "" || time.Since(c.tokenRenewTime) > c.renewTimeout {
+		if err := c.authenticate(); err != nil {
+			return "", fmt.Errorf("error getting auth token: %w", err)
+		}
+	}
+	return c.token, nil
+}
+
+// authenticate logs in or extends existing token, must be called with tokenLock held
+func (c *tokenClient) authenticate() error {
+	var res struct {
+		Token string `json:"token"`
+	}
+	if c.token != "" && c.extendPath != "" {
+		data, err := c.callWithToken("GET", c.extendPath, c.token, nil)
+		if err == nil {
+			err = json.Unmarshal(data, &res)
+		}
+		if err == nil {
+			c.token = res.Token
+			c.tokenRenewTime = time.Now()
+			return nil
+		}
+		log.Printf("[INFO] Error extending token of user %q, will re-login, %v", c.username, err)
+	}
+
+	loginData, err := json.Marshal(map[string]string{"username": c.username, "password": c.password})
+	if err != nil {
+		return fmt.Errorf("error marshaling login data: %w", err)
+	}
+	c.token = ""
+	data, err := c.callWithToken("POST", c.loginPath, "", bytes.NewReader(loginData))
+	if err != nil {
+		return fmt.Errorf("error logging in with user %q: %w", c.username, err)
+	}
+	if err := json.Unmarshal(data, &res); err != nil {
+		return fmt.Errorf("error obtaining token from login response: %w", err)
+	}
+	c.token = res.Token
+	c.tokenRenewTime = time.Now()
+	return nil
+}
+
+// bytesReader returns reader of given payload, or nil for nil payload
+func bytesReader(payload []byte) io.Reader {
+	if payload == nil {
+		return nil
+	}
+	return bytes.NewReader(payload)
+}
+
+func (c *tokenClient) callWithToken(method, url, token string, body io.Reader) ([]byte, error) {
+	req, err := http.NewRequest(method, c.apiURL+url, body)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set(c.authHeader, c.authPrefix+token)
+	}
+	response, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	defer response.Body.Close() // nolint:errcheck
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %w", err)
+	}
+	if response.StatusCode != http.StatusOK {
+		apiErr := &APIError{StatusCode: response.StatusCode, Status: response.Status, Body: data}
+		apiErr.RetryAfter = parseRetryAfter(response.Header.Get("Retry-After"), time.Now())
+		return nil, apiErr
+	}
+	return data, nil
+}
+
+// parseRetryAfter returns delay set by Retry-After header value, which is either
+// number of seconds or HTTP date, zero is returned for absent, malformed or past value
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
@@ -0,0 +1,117 @@
+// Copyright 2019 Booking.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrismaClient_Call(t *testing.T) {
+	logins, extends := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			assert.Equal(t, "POST", r.Method)
+			logins++
+			_, _ = fmt.Fprintf(w, `{"token":"login%d"}`, logins)
+		case "/auth_token/extend":
+			extends++
+			if extends > 1 {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = fmt.Fprint(w, `{"token":"extended"}`)
+		case "/check":
+			_, _ = fmt.Fprint(w, r.Header.Get("x-redlock-auth"))
+		case "/revoked":
+			body, _ := io.ReadAll(r.Body)
+			assert.Equal(t, "body", string(body), "Body is sent on every attempt")
+			// only token obtained after the rejection is accepted
+			if r.Header.Get("x-redlock-auth") != "login3" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = fmt.Fprint(w, r.Header.Get("x-redlock-auth"))
+		case "/limited":
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	c := newPrismaClient("user", "password", server.URL)
+	data, err := c.Call("GET", "/check", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "login1", string(data), "First call logs in")
+
+	c.tokenRenewTime = time.Time{}
+	data, err = c.Call("GET", "/check", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "extended", string(data), "Token is extended when renewal is due")
+
+	c.tokenRenewTime = time.Time{}
+	data, err = c.Call("GET", "/check", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "login2", string(data), "Failure to extend token results in new login")
+
+	data, err = c.Call("POST", "/revoked", strings.NewReader("body"))
+	assert.NoError(t, err)
+	assert.Equal(t, "login3", string(data), "Unauthorized request is repeated after new login")
+	assert.Equal(t, 3, logins)
+
+	_, err = c.Call("GET", "/limited", nil)
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, &APIError{StatusCode: http.StatusTooManyRequests, Status: "429 Too Many Requests",
+		Body: []byte{}, RetryAfter: time.Second * 7}, apiErr)
+	assert.EqualError(t, err, `429 Too Many Requests, response body: ""`)
+
+	_, err = newPrismaClient("user", "password", "http://[::1]:namedport").Call("GET", "/", nil)
+	assert.EqualError(t, err, `error getting auth token: error logging in with user "user": error creating request: `+
+		`parse "http://[::1]:namedport/login": invalid port ":namedport" after host`)
+	failing := newPrismaClient("user", "password", server.URL+"/nonexistent")
+	_, err = failing.Call("GET", "/", nil)
+	assert.EqualError(t, err, `error getting auth token: error logging in with user "user": 404 Not Found, response body: ""`)
+	assert.True(t, failing.tokenRenewTime.IsZero(), "Failed login doesn't postpone next login")
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	var testDataset = []struct {
+		value string
+		delay time.Duration
+	}{
+		{value: "", delay: 0},
+		{value: "7", delay: time.Second * 7},
+		{value: "-7", delay: 0},
+		{value: "Wed, 01 Jan 2020 12:01:30 GMT", delay: time.Second * 90},
+		{value: "Wed, 01 Jan 2020 11:59:00 GMT", delay: 0},
+		{value: "soon", delay: 0},
+	}
+	for i, x := range testDataset {
+		assert.Equal(t, x.delay, parseRetryAfter(x.value, now), "Test case %d delay check failed", i)
+	}
+}
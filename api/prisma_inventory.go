@@ -0,0 +1,58 @@
+// Copyright 2019 Booking.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// AssetCounts stores passed, failed and total resources count
+type AssetCounts struct {
+	PassedAssetsCount int `json:"passedResources"`
+	FailedAssetsCount int `json:"failedResources"`
+	TotalAssetsCount  int `json:"totalResources"`
+}
+
+// AssetGroupInfo stores resources count for single cloud type, service and resource type combination
+type AssetGroupInfo struct {
+	CloudType    string `json:"cloudTypeName"`
+	Service      string `json:"serviceName"`
+	ResourceType string `json:"resourceTypeName"`
+	AssetCounts
+}
+
+// AssetInventory stores overall resources count and its split by cloud type, service and resource type
+type AssetInventory struct {
+	Summary AssetCounts      `json:"summary"`
+	Groups  []AssetGroupInfo `json:"groupedAggregates"`
+}
+
+// GatherAssetInventory returns overall asset inventory information for last day,
+// unlike GatherComplianceInfo not limited to assets in scope of compliance standards
+// https://pan.dev/prisma-cloud/api/cspm/asset-inventory-v-2/
+func (p *Prisma) GatherAssetInventory() (*AssetInventory, error) {
+	data, err := p.api.Call("GET",
+		"/v2/inventory?timeType=to_now&timeUnit=day&groupBy=cloud.type,cloud.service,resource.type", nil)
+	if err != nil {
+		return nil, fmt.Errorf("error requesting asset inventory: %w", err)
+	}
+
+	var inventory AssetInventory
+	if err := json.Unmarshal(data, &inventory); err != nil {
+		return nil, fmt.Errorf("error unmarshaling asset inventory: %w", err)
+	}
+	return &inventory, nil
+}
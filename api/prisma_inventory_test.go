@@ -0,0 +1,58 @@
+// Copyright 2019 Booking.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrisma_GatherAssetInventory(t *testing.T) {
+	var testAPIRequestsDataset = []struct {
+		serverErr error
+		error     string
+		answer    []byte
+		inventory *AssetInventory
+	}{
+		{serverErr: fmt.Errorf("mock error"),
+			error: "error requesting asset inventory: mock error"},
+		{answer: []byte("not_json"),
+			error: "error unmarshaling asset inventory: invalid character 'o' in literal null (expecting 'u')"},
+		{answer: []byte(`{"summary":{"passedResources":10,"failedResources":5,"totalResources":15},
+"groupedAggregates":[{"cloudTypeName":"aws","serviceName":"Amazon EC2","resourceTypeName":"EC2 Instance",
+"passedResources":10,"failedResources":5,"totalResources":15}]}`),
+			inventory: &AssetInventory{
+				Summary: AssetCounts{PassedAssetsCount: 10, FailedAssetsCount: 5, TotalAssetsCount: 15},
+				Groups: []AssetGroupInfo{{CloudType: "aws", Service: "Amazon EC2", ResourceType: "EC2 Instance",
+					AssetCounts: AssetCounts{PassedAssetsCount: 10, FailedAssetsCount: 5, TotalAssetsCount: 15}}}}},
+	}
+
+	// start tests
+	p := &Prisma{}
+
+	for i, x := range testAPIRequestsDataset {
+		p.api = &mockClient{t: t, url: "/v2/inventory?timeType=to_now&timeUnit=day&groupBy=cloud.type,cloud.service,resource.type",
+			method: "GET", err: x.serverErr, answer: x.answer}
+		inventory, err := p.GatherAssetInventory()
+		if x.error != "" {
+			assert.EqualError(t, err, x.error, "Test case %d error check failed", i)
+		} else {
+			assert.NoError(t, err, "Test case %d error check failed", i)
+		}
+		assert.Equal(t, x.inventory, inventory, "Test case %d asset inventory check failed", i)
+	}
+}
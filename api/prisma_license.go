@@ -0,0 +1,88 @@
+// Copyright 2019 Booking.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/api/iterator"
+)
+
+// CloudTypeUsage stores credits consumption and resources count of single cloud type
+type CloudTypeUsage struct {
+	Credits        int
+	ResourceCounts map[string]int
+}
+
+// LicenseInfo stores licensed and consumed credits, overall and per cloud type
+type LicenseInfo struct {
+	LicensedCredits int
+	ConsumedCredits int
+	ByCloudType     map[string]CloudTypeUsage
+}
+
+// license stores fields of Prisma license information
+type license struct {
+	LicenseType     string `json:"licenseType"`
+	LicensedCredits int    `json:"workloadsPurchased"`
+}
+
+// licenseUsage stores fields of single license usage entry
+type licenseUsage struct {
+	CloudType         string         `json:"cloudType"`
+	ResourceTypeCount map[string]int `json:"resourceTypeCount"`
+	Total             int            `json:"total"`
+}
+
+// GatherLicenseInfo returns licensed credits and credits consumption over last month
+// https://pan.dev/prisma-cloud/api/cspm/get-license-info/
+// https://pan.dev/prisma-cloud/api/cspm/license-usage-count-by-cloud-paginated/
+func (p *Prisma) GatherLicenseInfo() (*LicenseInfo, error) {
+	data, err := p.api.Call("GET", "/license", nil)
+	if err != nil {
+		return nil, fmt.Errorf("error requesting license information: %w", err)
+	}
+	var lic license
+	if err := json.Unmarshal(data, &lic); err != nil {
+		return nil, fmt.Errorf("error unmarshaling license information: %w", err)
+	}
+
+	info := &LicenseInfo{LicensedCredits: lic.LicensedCredits, ByCloudType: map[string]CloudTypeUsage{}}
+	it := newPager[licenseUsage](p.api, postPageRequest("/license/api/v1/usage", map[string]interface{}{
+		"accountIds": []string{},
+		"timeRange":  map[string]interface{}{"type": "relative", "value": map[string]interface{}{"amount": 1, "unit": "month"}},
+	}), 0, 0)
+	for {
+		item, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error requesting license usage: %w", err)
+		}
+		info.ConsumedCredits += item.Total
+		cloudUsage, ok := info.ByCloudType[item.CloudType]
+		if !ok {
+			cloudUsage.ResourceCounts = map[string]int{}
+		}
+		cloudUsage.Credits += item.Total
+		for resourceType, count := range item.ResourceTypeCount {
+			cloudUsage.ResourceCounts[resourceType] += count
+		}
+		info.ByCloudType[item.CloudType] = cloudUsage
+	}
+	return info, nil
+}
@@ -0,0 +1,70 @@
+// Copyright 2019 Booking.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrisma_GatherLicenseInfo(t *testing.T) {
+	var testAPIRequestsDataset = []struct {
+		license   mockResponse
+		usage     mockResponse
+		usageNext mockResponse
+		error     string
+		info      *LicenseInfo
+	}{
+		{license: mockResponse{err: fmt.Errorf("mock error")},
+			error: "error requesting license information: mock error"},
+		{license: mockResponse{answer: []byte("not_json")},
+			error: "error unmarshaling license information: invalid character 'o' in literal null (expecting 'u')"},
+		{license: mockResponse{answer: []byte(`{}`)}, usage: mockResponse{err: fmt.Errorf("mock error")},
+			error: "error requesting license usage: mock error"},
+		{license: mockResponse{answer: []byte(`{}`)}, usage: mockResponse{answer: []byte("not_json")},
+			error: "error requesting license usage: error unmarshaling page 1: invalid character 'o' in literal null (expecting 'u')"},
+		{license: mockResponse{answer: []byte(`{"licenseType":"enterprise","workloadsPurchased":1000}`)},
+			usage: mockResponse{answer: []byte(`{"items":[
+{"cloudType":"aws","resourceTypeCount":{"compute":10,"database":2},"total":12},
+{"cloudType":"aws","resourceTypeCount":{"compute":5},"total":5}],"nextPageToken":"next"}`)},
+			usageNext: mockResponse{answer: []byte(`{"items":[
+{"cloudType":"gcp","resourceTypeCount":{"compute":1},"total":1}]}`)},
+			info: &LicenseInfo{LicensedCredits: 1000, ConsumedCredits: 18, ByCloudType: map[string]CloudTypeUsage{
+				"aws": {Credits: 17, ResourceCounts: map[string]int{"compute": 15, "database": 2}},
+				"gcp": {Credits: 1, ResourceCounts: map[string]int{"compute": 1}},
+			}}},
+	}
+
+	// start tests
+	p := &Prisma{}
+
+	for i, x := range testAPIRequestsDataset {
+		p.api = &mockRoutes{t: t, routes: map[string]mockResponse{
+			"GET /license":               x.license,
+			"POST /license/api/v1/usage": x.usage,
+			`POST /license/api/v1/usage {"accountIds":[],"limit":1000,"pageToken":"next",` +
+				`"timeRange":{"type":"relative","value":{"amount":1,"unit":"month"}}}`: x.usageNext,
+		}}
+		info, err := p.GatherLicenseInfo()
+		if x.error != "" {
+			assert.EqualError(t, err, x.error, "Test case %d error check failed", i)
+		} else {
+			assert.NoError(t, err, "Test case %d error check failed", i)
+		}
+		assert.Equal(t, x.info, info, "Test case %d license info check failed", i)
+	}
+}
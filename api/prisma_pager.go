@@ -0,0 +1,161 @@
+// Copyright 2019 Booking.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"google.golang.org/api/iterator"
+)
+
+// Prisma list endpoints page size and safeguard against endless pagination
+const (
+	defaultPageSize = 1000
+	maxPageSize     = 10000
+	defaultMaxPages = 100
+)
+
+// errPagesLimit is returned by pager when more than maxPages pages are available
+var errPagesLimit = errors.New("result is truncated")
+
+// pageRequestFunc returns request for the page with given token, which is empty for the first page
+type pageRequestFunc func(pageToken string, pageSize int) (method, url string, body io.Reader, err error)
+
+// pageDecodeFunc returns page from response data of endpoint with JSON scheme other than pageResponse
+type pageDecodeFunc[T any] func(data []byte) (pageResponse[T], error)
+
+// pageResponse is the JSON scheme of single page of Prisma list endpoints
+type pageResponse[T any] struct {
+	Items         []T    `json:"items"`
+	NextPageToken string `json:"nextPageToken"`
+}
+
+// pager iterates over items of Prisma list endpoint following nextPageToken,
+// keeping only single page in memory
+type pager[T any] struct {
+	api      apiCaller
+	request  pageRequestFunc
+	pageSize int
+	maxPages int
+	// pageResponse JSON scheme is expected when not set
+	decode pageDecodeFunc[T]
+
+	items     []T
+	nextToken string
+	pages     int
+	done      bool
+}
+
+// newPager returns pager over items returned by given request function,
+// page size is capped by maxPageSize and non-positive values are replaced by defaults
+func newPager[T any](api apiCaller, request pageRequestFunc, pageSize, maxPages int) *pager[T] {
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+	if maxPages <= 0 {
+		maxPages = defaultMaxPages
+	}
+	return &pager[T]{api: api, request: request, pageSize: pageSize, maxPages: maxPages}
+}
+
+// getPageRequest returns pageRequestFunc for GET endpoint with given URL,
+// passing page size and token as query parameters
+func getPageRequest(endpoint string) pageRequestFunc {
+	return func(pageToken string, pageSize int) (string, string, io.Reader, error) {
+		separator := "?"
+		if strings.Contains(endpoint, "?") {
+			separator = "&"
+		}
+		u := fmt.Sprintf("%s%slimit=%d", endpoint, separator, pageSize)
+		if pageToken != "" {
+			u += "&pageToken=" + url.QueryEscape(pageToken)
+		}
+		return "GET", u, nil, nil
+	}
+}
+
+// postPageRequest returns pageRequestFunc for POST endpoint with given URL and request body,
+// passing page size and token as limit and pageToken body fields
+func postPageRequest(endpoint string, body map[string]interface{}) pageRequestFunc {
+	return func(pageToken string, pageSize int) (string, string, io.Reader, error) {
+		request := make(map[string]interface{}, len(body)+2)
+		for k, v := range body {
+			request[k] = v
+		}
+		request["limit"] = pageSize
+		if pageToken != "" {
+			request["pageToken"] = pageToken
+		}
+		data, err := json.Marshal(request)
+		if err != nil {
+			return "", "", nil, fmt.Errorf("error marshaling page request: %w", err)
+		}
+		return "POST", endpoint, bytes.NewReader(data), nil
+	}
+}
+
+// Next returns next item, iterator.Done when there are no more items,
+// or error when request failed or more than maxPages pages are available
+func (p *pager[T]) Next() (T, error) {
+	var item T
+	for len(p.items) == 0 {
+		if p.done {
+			return item, iterator.Done
+		}
+		if p.pages >= p.maxPages {
+			return item, fmt.Errorf("%w: more than %d pages of %d items available", errPagesLimit, p.maxPages, p.pageSize)
+		}
+		if err := p.fetch(); err != nil {
+			return item, err
+		}
+	}
+	item, p.items = p.items[0], p.items[1:]
+	return item, nil
+}
+
+// fetch requests next page
+func (p *pager[T]) fetch() error {
+	method, u, body, err := p.request(p.nextToken, p.pageSize)
+	if err != nil {
+		return err
+	}
+	data, err := p.api.Call(method, u, body)
+	if err != nil {
+		return err
+	}
+	var page pageResponse[T]
+	if p.decode != nil {
+		page, err = p.decode(data)
+	} else {
+		err = json.Unmarshal(data, &page)
+	}
+	if err != nil {
+		return fmt.Errorf("error unmarshaling page %d: %w", p.pages+1, err)
+	}
+	p.pages++
+	p.items = page.Items
+	p.nextToken = page.NextPageToken
+	p.done = page.NextPageToken == ""
+	return nil
+}
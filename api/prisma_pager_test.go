@@ -0,0 +1,109 @@
+// Copyright 2019 Booking.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/iterator"
+)
+
+func TestPager_Next(t *testing.T) {
+	var testDataset = []struct {
+		routes   map[string]mockResponse
+		pageSize int
+		maxPages int
+		error    string
+		items    []int
+	}{
+		{routes: map[string]mockResponse{"GET /list?limit=1000": {err: fmt.Errorf("mock error")}},
+			error: "mock error"},
+		{routes: map[string]mockResponse{"GET /list?limit=1000": {answer: []byte("not_json")}},
+			error: "error unmarshaling page 1: invalid character 'o' in literal null (expecting 'u')"},
+		{routes: map[string]mockResponse{"GET /list?limit=1000": {answer: []byte(`{"items":[]}`)}},
+			items: nil},
+		{routes: map[string]mockResponse{
+			"GET /list?limit=2":               {answer: []byte(`{"items":[1,2],"nextPageToken":"a b"}`)},
+			"GET /list?limit=2&pageToken=a+b": {answer: []byte(`{"items":[],"nextPageToken":"c"}`)},
+			"GET /list?limit=2&pageToken=c":   {answer: []byte(`{"items":[3],"nextPageToken":"d"}`)},
+			"GET /list?limit=2&pageToken=d":   {answer: []byte(`{"items":[4]}`)}},
+			pageSize: 2, items: []int{1, 2, 3, 4}},
+		{routes: map[string]mockResponse{
+			"GET /list?limit=10000":             {answer: []byte(`{"items":[1],"nextPageToken":"a"}`)},
+			"GET /list?limit=10000&pageToken=a": {answer: []byte(`{"items":[2],"nextPageToken":"b"}`)}},
+			pageSize: 20000, maxPages: 2, items: []int{1, 2},
+			error: "result is truncated: more than 2 pages of 10000 items available"},
+	}
+
+	for i, x := range testDataset {
+		p := newPager[int](&mockRoutes{t: t, routes: x.routes}, getPageRequest("/list"), x.pageSize, x.maxPages)
+		var items []int
+		var err error
+		for {
+			var item int
+			if item, err = p.Next(); err != nil {
+				break
+			}
+			items = append(items, item)
+		}
+		if x.error != "" {
+			assert.EqualError(t, err, x.error, "Test case %d error check failed", i)
+		} else {
+			assert.Equal(t, iterator.Done, err, "Test case %d error check failed", i)
+			// exhausted pager keeps returning iterator.Done without new requests
+			_, err = p.Next()
+			assert.Equal(t, iterator.Done, err, "Test case %d repeated Next check failed", i)
+		}
+		assert.Equal(t, x.items, items, "Test case %d items check failed", i)
+	}
+}
+
+func TestNewPager(t *testing.T) {
+	p := newPager[int](&mockClient{}, getPageRequest("/list"), 0, 0)
+	assert.Equal(t, defaultPageSize, p.pageSize)
+	assert.Equal(t, defaultMaxPages, p.maxPages)
+	p = newPager[int](&mockClient{}, getPageRequest("/list"), maxPageSize+1, 5)
+	assert.Equal(t, maxPageSize, p.pageSize)
+	assert.Equal(t, 5, p.maxPages)
+}
+
+func TestGetPageRequest(t *testing.T) {
+	method, url, body, err := getPageRequest("/v2/alert")("", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, "GET", method)
+	assert.Equal(t, "/v2/alert?limit=10", url)
+	assert.Nil(t, body)
+	_, url, _, _ = getPageRequest("/v2/alert?alert.status=open")("token/with=chars", 10)
+	assert.Equal(t, "/v2/alert?alert.status=open&limit=10&pageToken=token%2Fwith%3Dchars", url)
+}
+
+func TestPostPageRequest(t *testing.T) {
+	request := postPageRequest("/search", map[string]interface{}{"query": "q"})
+	method, url, body, err := request("", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, "POST", method)
+	assert.Equal(t, "/search", url)
+	data, _ := io.ReadAll(body)
+	assert.JSONEq(t, `{"query":"q","limit":10}`, string(data))
+	_, _, body, _ = request("token", 10)
+	data, _ = io.ReadAll(body)
+	assert.JSONEq(t, `{"query":"q","limit":10,"pageToken":"token"}`, string(data))
+
+	_, _, _, err = postPageRequest("/search", map[string]interface{}{"bad": make(chan int)})("", 10)
+	assert.EqualError(t, err, "error marshaling page request: json: unsupported type: chan int")
+}
@@ -0,0 +1,95 @@
+// Copyright 2019 Booking.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/api/iterator"
+)
+
+// PolicyCounts stores number of enabled and disabled policies
+type PolicyCounts struct {
+	Enabled  int
+	Disabled int
+}
+
+// PolicyInventory stores policies counts, overall and split by origin, severity and policy type
+type PolicyInventory struct {
+	Total      PolicyCounts
+	Custom     PolicyCounts
+	System     PolicyCounts
+	BySeverity map[string]PolicyCounts
+	ByType     map[string]PolicyCounts
+}
+
+// policy stores fields of single Prisma policy required for inventory calculation
+type policy struct {
+	PolicyID      string `json:"policyId"`
+	PolicyType    string `json:"policyType"`
+	Severity      string `json:"severity"`
+	Enabled       bool   `json:"enabled"`
+	SystemDefault bool   `json:"systemDefault"`
+}
+
+// GatherPolicyInventory returns policies counts
+// https://pan.dev/prisma-cloud/api/cspm/get-policies-v-2/
+func (p *Prisma) GatherPolicyInventory() (*PolicyInventory, error) {
+	inventory := &PolicyInventory{BySeverity: map[string]PolicyCounts{}, ByType: map[string]PolicyCounts{}}
+	it := newPager[policy](p.api, getPageRequest("/v2/policy"), 0, 0)
+	it.decode = decodePoliciesPage
+	for {
+		pol, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error requesting policies information: %w", err)
+		}
+		inventory.Total = inventory.Total.add(pol.Enabled)
+		if pol.SystemDefault {
+			inventory.System = inventory.System.add(pol.Enabled)
+		} else {
+			inventory.Custom = inventory.Custom.add(pol.Enabled)
+		}
+		inventory.BySeverity[pol.Severity] = inventory.BySeverity[pol.Severity].add(pol.Enabled)
+		inventory.ByType[pol.PolicyType] = inventory.ByType[pol.PolicyType].add(pol.Enabled)
+	}
+	return inventory, nil
+}
+
+// decodePoliciesPage returns page of policies from response data, which is either
+// page with nextPageToken or plain list of all policies, the latter is the last page
+func decodePoliciesPage(data []byte) (pageResponse[policy], error) {
+	var page pageResponse[policy]
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		err := json.Unmarshal(trimmed, &page.Items)
+		return page, err
+	}
+	err := json.Unmarshal(data, &page)
+	return page, err
+}
+
+// add returns counts with single policy of given state added
+func (c PolicyCounts) add(enabled bool) PolicyCounts {
+	if enabled {
+		c.Enabled++
+	} else {
+		c.Disabled++
+	}
+	return c
+}
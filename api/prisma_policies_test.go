@@ -0,0 +1,84 @@
+// Copyright 2019 Booking.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrisma_GatherPolicyInventory(t *testing.T) {
+	var testAPIRequestsDataset = []struct {
+		serverErr error
+		error     string
+		answer    []byte
+		inventory *PolicyInventory
+	}{
+		{serverErr: fmt.Errorf("mock error"),
+			error: "error requesting policies information: mock error"},
+		{answer: []byte("not_json"),
+			error: "error requesting policies information: error unmarshaling page 1: invalid character 'o' in literal null (expecting 'u')"},
+		{answer: []byte(`[
+{"policyId":"1","policyType":"config","severity":"high","enabled":true,"systemDefault":true},
+{"policyId":"2","policyType":"config","severity":"low","enabled":false,"systemDefault":true},
+{"policyId":"3","policyType":"network","severity":"high","enabled":true,"systemDefault":false}]`),
+			inventory: &PolicyInventory{
+				Total:  PolicyCounts{Enabled: 2, Disabled: 1},
+				Custom: PolicyCounts{Enabled: 1},
+				System: PolicyCounts{Enabled: 1, Disabled: 1},
+				BySeverity: map[string]PolicyCounts{
+					"high": {Enabled: 2},
+					"low":  {Disabled: 1},
+				},
+				ByType: map[string]PolicyCounts{
+					"config":  {Enabled: 1, Disabled: 1},
+					"network": {Enabled: 1},
+				}}},
+	}
+
+	// start tests
+	p := &Prisma{}
+
+	for i, x := range testAPIRequestsDataset {
+		p.api = &mockClient{t: t, url: "/v2/policy?limit=1000", method: "GET", err: x.serverErr, answer: x.answer}
+		inventory, err := p.GatherPolicyInventory()
+		if x.error != "" {
+			assert.EqualError(t, err, x.error, "Test case %d error check failed", i)
+		} else {
+			assert.NoError(t, err, "Test case %d error check failed", i)
+		}
+		assert.Equal(t, x.inventory, inventory, "Test case %d policy inventory check failed", i)
+	}
+}
+
+func TestPrisma_GatherPolicyInventoryPages(t *testing.T) {
+	p := &Prisma{api: &mockRoutes{t: t, routes: map[string]mockResponse{
+		"GET /v2/policy?limit=1000": {answer: []byte(`{"items":[
+{"policyId":"1","policyType":"config","severity":"high","enabled":true,"systemDefault":true}],"nextPageToken":"next"}`)},
+		"GET /v2/policy?limit=1000&pageToken=next": {answer: []byte(`{"items":[
+{"policyId":"2","policyType":"config","severity":"low","enabled":false,"systemDefault":false}]}`)},
+	}}}
+	inventory, err := p.GatherPolicyInventory()
+	assert.NoError(t, err)
+	assert.Equal(t, &PolicyInventory{
+		Total:      PolicyCounts{Enabled: 1, Disabled: 1},
+		Custom:     PolicyCounts{Disabled: 1},
+		System:     PolicyCounts{Enabled: 1},
+		BySeverity: map[string]PolicyCounts{"high": {Enabled: 1}, "low": {Disabled: 1}},
+		ByType:     map[string]PolicyCounts{"config": {Enabled: 1, Disabled: 1}},
+	}, inventory)
+}
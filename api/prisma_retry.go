@@ -0,0 +1,135 @@
+// Copyright 2019 Booking.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// default retry policy of Prisma API calls
+const (
+	defaultMaxRetries        = 3
+	defaultRequestsPerSecond = 2
+	retryBaseDelay           = time.Second
+	retryMaxDelay            = time.Second * 30
+	// longer Retry-After server response header value is capped to it
+	retryAfterMaxDelay = time.Minute * 2
+)
+
+// CallStats stores Prisma API calls counters since client creation
+type CallStats struct {
+	Calls       int64
+	Retries     int64
+	RateLimited int64
+	Failures    int64
+}
+
+// retryCaller wraps apiCaller, limiting requests rate and retrying
+// rate limited, timed out and failed on server side requests with exponential backoff
+type retryCaller struct {
+	api        apiCaller
+	limiter    *rate.Limiter
+	maxRetries int
+	// time.Sleep is used when not set
+	sleep func(time.Duration)
+
+	calls       atomic.Int64
+	retries     atomic.Int64
+	rateLimited atomic.Int64
+	failures    atomic.Int64
+}
+
+func newRetryCaller(api apiCaller) *retryCaller {
+	return &retryCaller{api: api, limiter: rate.NewLimiter(defaultRequestsPerSecond, 1), maxRetries: defaultMaxRetries}
+}
+
+// Call makes request through wrapped apiCaller, retrying it when it's reasonable;
+// Retry-After server response header takes precedence over calculated backoff delay when it's longer,
+// but is capped by retryAfterMaxDelay
+func (r *retryCaller) Call(method, url string, body io.Reader) ([]byte, error) {
+	r.calls.Add(1)
+	// body is read in advance to be re-sent on retries
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = io.ReadAll(body); err != nil {
+			r.failures.Add(1)
+			return nil, fmt.Errorf("error reading request body: %w", err)
+		}
+	}
+	for attempt := 0; ; attempt++ {
+		var attemptBody io.Reader
+		if payload != nil {
+			attemptBody = bytes.NewReader(payload)
+		}
+		data, err := r.callOnce(method, url, attemptBody)
+		if err == nil {
+			return data, nil
+		}
+		class, _ := classifyAPIError(err)
+		if class == HealthErrorRateLimit {
+			r.rateLimited.Add(1)
+		}
+		if attempt >= r.maxRetries || (class != HealthErrorRateLimit && class != HealthErrorTimeout &&
+			class != HealthErrorServer && class != HealthErrorNetwork) {
+			r.failures.Add(1)
+			return nil, err
+		}
+		delay := backoffDelay(attempt)
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > delay {
+			delay = min(apiErr.RetryAfter, retryAfterMaxDelay)
+		}
+		r.retries.Add(1)
+		if r.sleep != nil {
+			r.sleep(delay)
+		} else {
+			time.Sleep(delay)
+		}
+	}
+}
+
+// callOnce makes single request through wrapped apiCaller, waiting for rate limiter beforehand
+func (r *retryCaller) callOnce(method, url string, body io.Reader) ([]byte, error) {
+	if err := r.limiter.Wait(context.Background()); err != nil {
+		return nil, fmt.Errorf("rate limiter error: %w", err)
+	}
+	return r.api.Call(method, url, body)
+}
+
+// stats returns calls counters
+func (r *retryCaller) stats() CallStats {
+	return CallStats{Calls: r.calls.Load(), Retries: r.retries.Load(),
+		RateLimited: r.rateLimited.Load(), Failures: r.failures.Load()}
+}
+
+// backoffDelay returns exponential delay with jitter for given zero-based attempt number,
+// which is random value between half and full of exponential delay capped by retryMaxDelay
+func backoffDelay(attempt int) time.Duration {
+	delay := retryMaxDelay
+	if attempt < 16 && retryBaseDelay<<attempt < retryMaxDelay {
+		delay = retryBaseDelay << attempt
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1)) // nolint:gosec // jitter doesn't need crypto random
+}
@@ -0,0 +1,124 @@
+// Copyright 2019 Booking.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+)
+
+func TestRetryCaller_Call(t *testing.T) {
+	netErr := &net.OpError{Op: "dial", Err: fmt.Errorf("connection refused")}
+	var testDataset = []struct {
+		responses []mockResponse
+		error     string
+		answer    []byte
+		delays    []time.Duration
+		stats     CallStats
+	}{
+		{responses: []mockResponse{{answer: []byte("ok")}},
+			answer: []byte("ok"), stats: CallStats{Calls: 1}},
+		{responses: []mockResponse{{err: &APIError{StatusCode: 429, Status: "429 Too Many Requests", RetryAfter: time.Minute}},
+			{err: &APIError{StatusCode: 503, Status: "503 Service Unavailable"}}, {answer: []byte("ok")}},
+			answer: []byte("ok"), delays: []time.Duration{time.Minute, time.Second * 2}, stats: CallStats{Calls: 1, Retries: 2, RateLimited: 1}},
+		{responses: []mockResponse{{err: &APIError{StatusCode: 401, Status: "401 Unauthorized"}}},
+			error: `401 Unauthorized, response body: ""`, stats: CallStats{Calls: 1, Failures: 1}},
+		{responses: []mockResponse{{err: netErr}, {err: netErr}, {err: netErr}, {err: netErr}},
+			error: "dial: connection refused", delays: []time.Duration{time.Second, time.Second * 2, time.Second * 4},
+			stats: CallStats{Calls: 1, Retries: 3, Failures: 1}},
+		{responses: []mockResponse{{err: fmt.Errorf("mock error")}},
+			error: "mock error", stats: CallStats{Calls: 1, Failures: 1}},
+		{responses: []mockResponse{{err: &APIError{StatusCode: 429, Status: "429 Too Many Requests", RetryAfter: time.Hour}},
+			{answer: []byte("ok")}},
+			answer: []byte("ok"), delays: []time.Duration{retryAfterMaxDelay}, stats: CallStats{Calls: 1, Retries: 1, RateLimited: 1}},
+	}
+
+	for i, x := range testDataset {
+		m := &mockSequence{t: t, responses: x.responses, body: "request"}
+		var delays []time.Duration
+		r := newRetryCaller(m)
+		r.limiter = rate.NewLimiter(rate.Inf, 1)
+		r.sleep = func(d time.Duration) { delays = append(delays, d) }
+		answer, err := r.Call("POST", "/test", bytes.NewBufferString("request"))
+		if x.error != "" {
+			assert.EqualError(t, err, x.error, "Test case %d error check failed", i)
+		} else {
+			assert.NoError(t, err, "Test case %d error check failed", i)
+		}
+		assert.Equal(t, x.answer, answer, "Test case %d answer check failed", i)
+		assert.Equal(t, len(x.delays), len(delays), "Test case %d retries check failed", i)
+		for j := range delays {
+			// Retry-After is used as is unless capped, backoff is jittered to be between half and full of exponential delay
+			assert.LessOrEqual(t, delays[j], x.delays[j], "Test case %d delay %d check failed", i, j)
+			assert.GreaterOrEqual(t, delays[j], x.delays[j]/2, "Test case %d delay %d check failed", i, j)
+		}
+		assert.Equal(t, x.stats, r.stats(), "Test case %d stats check failed", i)
+		assert.Empty(t, m.responses, "Test case %d all responses are used", i)
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	for attempt := 0; attempt < 100; attempt++ {
+		delay := backoffDelay(attempt)
+		assert.LessOrEqual(t, delay, retryMaxDelay)
+		assert.GreaterOrEqual(t, delay, retryBaseDelay/2)
+	}
+}
+
+func TestPrisma_SetRetryPolicy(t *testing.T) {
+	p := NewPrisma("key", "secret", "bad_host")
+	p.SetRetryPolicy(5, 10)
+	r := p.api.(*retryCaller)
+	assert.Equal(t, 5, r.maxRetries)
+	assert.Equal(t, rate.Limit(10), r.limiter.Limit())
+	p.SetRetryPolicy(0, 0)
+	assert.Equal(t, rate.Inf, r.limiter.Limit(), "Zero rate disables rate limiting")
+	assert.Equal(t, CallStats{}, p.GetCallStats())
+	assert.Equal(t, CallStats{}, (&Prisma{api: &mockClient{}}).GetCallStats())
+}
+
+func TestPrisma_GetAPIHealthNotLimited(t *testing.T) {
+	r := newRetryCaller(&mockClient{t: t, url: "/check", method: "GET"})
+	// limiter which never allows a request
+	r.limiter = rate.NewLimiter(0, 0)
+	p := &Prisma{api: r}
+	h := p.GetAPIHealth()
+	h.Latency = 0
+	assert.Equal(t, APIHealth{StatusCode: 200}, h)
+	assert.Equal(t, CallStats{}, p.GetCallStats(), "Health check is not counted as rate limited call")
+}
+
+// mockSequence answers requests with given responses one by one, checking request body
+type mockSequence struct {
+	t         *testing.T
+	body      string
+	responses []mockResponse
+}
+
+func (m *mockSequence) Call(_, _ string, body io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(body)
+	assert.NoError(m.t, err)
+	assert.Equal(m.t, m.body, string(data))
+	r := m.responses[0]
+	m.responses = m.responses[1:]
+	return r.answer, r.err
+}
@@ -0,0 +1,225 @@
+// Copyright 2019 Booking.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"google.golang.org/api/iterator"
+)
+
+// RQL search types, each of them is served by separate Prisma endpoint
+const (
+	RQLConfig  = "config"
+	RQLNetwork = "network"
+	RQLEvent   = "event"
+)
+
+// maximum number of items requested from RQL search at once, used for group by counts
+const rqlSearchLimit = 10000
+
+// RQLQuery stores named RQL query and its run schedule
+type RQLQuery struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Query string `json:"query"`
+	// name of the result item JSON field to count results by, optional
+	GroupBy string        `json:"group_by"`
+	Period  time.Duration `json:"-"`
+}
+
+// RQLResult stores result count of named RQL query
+type RQLResult struct {
+	Name   string
+	Count  int
+	Groups map[string]int
+	// true if not all result items were received, so Groups or network query Count are incomplete
+	Truncated bool
+}
+
+// rqlSearchResponse is required to unwrap the nested JSON scheme of config and event search response
+type rqlSearchResponse struct {
+	Data struct {
+		TotalRows     int                      `json:"totalRows"`
+		Items         []map[string]interface{} `json:"items"`
+		NextPageToken string                   `json:"nextPageToken"`
+	} `json:"data"`
+}
+
+// rqlNetworkResponse is required to unwrap the nested JSON scheme of network search response
+type rqlNetworkResponse struct {
+	Data struct {
+		Nodes []map[string]interface{} `json:"nodes"`
+	} `json:"data"`
+}
+
+// ParseRQLQueries returns RQL queries from given JSON list, where every entry has
+// name, type (config, network or event), query, period (Go duration) and optional group_by field
+func ParseRQLQueries(data []byte) ([]RQLQuery, error) {
+	var entries []struct {
+		RQLQuery
+		Period string `json:"period"`
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("error unmarshaling RQL queries: %w", err)
+	}
+	queries := make([]RQLQuery, 0, len(entries))
+	names := map[string]bool{}
+	for _, e := range entries {
+		if e.Name == "" || e.Query == "" {
+			return nil, fmt.Errorf("RQL query name and query text are required, got %q", e.Name)
+		}
+		if names[e.Name] {
+			return nil, fmt.Errorf("duplicate RQL query name %q", e.Name)
+		}
+		names[e.Name] = true
+		switch e.Type {
+		case RQLConfig, RQLNetwork, RQLEvent:
+		default:
+			return nil, fmt.Errorf("unknown RQL query %q type %q", e.Name, e.Type)
+		}
+		period, err := time.ParseDuration(e.Period)
+		if err != nil || period < time.Minute {
+			return nil, fmt.Errorf("bad RQL query %q period %q, it should be at least 1m", e.Name, e.Period)
+		}
+		q := e.RQLQuery
+		q.Period = period
+		queries = append(queries, q)
+	}
+	return queries, nil
+}
+
+// RunRQLQuery runs given RQL query and returns its result count; event queries are limited
+// to events which happened within query period, config and network ones are not limited in time.
+// Config and event queries count is taken from total rows, so their items are requested only for group by,
+// walking config search pages; event search results are not paged and network search has no total,
+// so network query count is number of returned nodes
+// https://pan.dev/prisma-cloud/api/cspm/search-config/
+// https://pan.dev/prisma-cloud/api/cspm/search-network/
+// https://pan.dev/prisma-cloud/api/cspm/search-event/
+func (p *Prisma) RunRQLQuery(q RQLQuery) (*RQLResult, error) {
+	request := map[string]interface{}{
+		"query":     q.Query,
+		"timeRange": map[string]interface{}{"type": "to_now", "value": "epoch"},
+	}
+	if q.Type == RQLEvent {
+		request["timeRange"] = map[string]interface{}{"type": "relative",
+			"value": map[string]interface{}{"unit": "minute", "amount": int(q.Period.Minutes())}}
+	}
+	if q.Type == RQLNetwork {
+		return p.runRQLNetworkQuery(q, request)
+	}
+
+	result := &RQLResult{Name: q.Name}
+	pageSize, maxPages := rqlSearchLimit, 0
+	if q.GroupBy == "" {
+		pageSize, maxPages = 1, 1
+	}
+	if q.Type == RQLEvent {
+		maxPages = 1
+	}
+	it := newPager[map[string]interface{}](p.api, rqlPageRequest("/search/"+q.Type, request), pageSize, maxPages)
+	it.decode = func(data []byte) (pageResponse[map[string]interface{}], error) {
+		var response rqlSearchResponse
+		if err := json.Unmarshal(data, &response); err != nil {
+			return pageResponse[map[string]interface{}]{}, err
+		}
+		// pages after the first one might not report total rows
+		if response.Data.TotalRows > result.Count {
+			result.Count = response.Data.TotalRows
+		}
+		return pageResponse[map[string]interface{}]{Items: response.Data.Items, NextPageToken: response.Data.NextPageToken}, nil
+	}
+
+	if q.GroupBy == "" {
+		// first page holds total rows count
+		if _, err := it.Next(); err != nil && err != iterator.Done {
+			return nil, fmt.Errorf("error running RQL query %q: %w", q.Name, err)
+		}
+		return result, nil
+	}
+	result.Groups = map[string]int{}
+	counted := 0
+	for {
+		item, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if errors.Is(err, errPagesLimit) {
+			result.Truncated = true
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error running RQL query %q: %w", q.Name, err)
+		}
+		countRQLGroup(result.Groups, item, q.GroupBy)
+		counted++
+	}
+	result.Truncated = result.Truncated || counted < result.Count
+	return result, nil
+}
+
+// runRQLNetworkQuery runs given network RQL query with given request fields
+func (p *Prisma) runRQLNetworkQuery(q RQLQuery, request map[string]interface{}) (*RQLResult, error) {
+	request["limit"] = rqlSearchLimit
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling RQL query %q: %w", q.Name, err)
+	}
+	data, err := p.api.Call("POST", "/search", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("error running RQL query %q: %w", q.Name, err)
+	}
+	var response rqlNetworkResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("error unmarshaling RQL query %q result: %w", q.Name, err)
+	}
+	result := &RQLResult{Name: q.Name, Count: len(response.Data.Nodes),
+		Truncated: len(response.Data.Nodes) >= rqlSearchLimit}
+	if q.GroupBy != "" {
+		result.Groups = map[string]int{}
+		for _, node := range response.Data.Nodes {
+			countRQLGroup(result.Groups, node, q.GroupBy)
+		}
+	}
+	return result, nil
+}
+
+// rqlPageRequest returns pageRequestFunc for RQL search endpoint with given URL and request fields,
+// with pages after the first one requested from its page endpoint
+func rqlPageRequest(url string, request map[string]interface{}) pageRequestFunc {
+	first, next := postPageRequest(url, request), postPageRequest(url+"/page", nil)
+	return func(pageToken string, pageSize int) (string, string, io.Reader, error) {
+		if pageToken == "" {
+			return first(pageToken, pageSize)
+		}
+		return next(pageToken, pageSize)
+	}
+}
+
+// countRQLGroup increments count of given RQL result item group, which is value of given field
+func countRQLGroup(groups map[string]int, item map[string]interface{}, groupBy string) {
+	value, ok := item[groupBy]
+	if !ok || value == nil {
+		groups["none"]++
+		return
+	}
+	groups[fmt.Sprint(value)]++
+}
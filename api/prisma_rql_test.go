@@ -0,0 +1,130 @@
+// Copyright 2019 Booking.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRQLQueries(t *testing.T) {
+	var testDataset = []struct {
+		data    string
+		error   string
+		queries []RQLQuery
+	}{
+		{data: "not_json",
+			error: "error unmarshaling RQL queries: invalid character 'o' in literal null (expecting 'u')"},
+		{data: `[{"name":"q","type":"config","period":"1h"}]`,
+			error: `RQL query name and query text are required, got "q"`},
+		{data: `[{"name":"q","type":"bad","query":"x","period":"1h"}]`,
+			error: `unknown RQL query "q" type "bad"`},
+		{data: `[{"name":"q","type":"event","query":"x","period":"bad"}]`,
+			error: `bad RQL query "q" period "bad", it should be at least 1m`},
+		{data: `[{"name":"q","type":"event","query":"x","period":"30s"}]`,
+			error: `bad RQL query "q" period "30s", it should be at least 1m`},
+		{data: `[{"name":"q","type":"event","query":"x","period":"1h"},{"name":"q","type":"event","query":"y","period":"1h"}]`,
+			error: `duplicate RQL query name "q"`},
+		{data: `[{"name":"public_buckets","type":"config","query":"config from cloud.resource","period":"1h","group_by":"accountName"}]`,
+			queries: []RQLQuery{{Name: "public_buckets", Type: RQLConfig, Query: "config from cloud.resource",
+				GroupBy: "accountName", Period: time.Hour}}},
+	}
+
+	for i, x := range testDataset {
+		queries, err := ParseRQLQueries([]byte(x.data))
+		if x.error != "" {
+			assert.EqualError(t, err, x.error, "Test case %d error check failed", i)
+		} else {
+			assert.NoError(t, err, "Test case %d error check failed", i)
+		}
+		assert.Equal(t, x.queries, queries, "Test case %d queries check failed", i)
+	}
+}
+
+func TestPrisma_RunRQLQuery(t *testing.T) {
+	var testAPIRequestsDataset = []struct {
+		query     RQLQuery
+		url       string
+		serverErr error
+		answer    []byte
+		error     string
+		result    *RQLResult
+	}{
+		{query: RQLQuery{Name: "q", Type: RQLConfig}, url: "/search/config", serverErr: fmt.Errorf("mock error"),
+			error: `error running RQL query "q": mock error`},
+		{query: RQLQuery{Name: "q", Type: RQLEvent, Period: time.Hour}, url: "/search/event", answer: []byte("not_json"),
+			error: `error running RQL query "q": error unmarshaling page 1: invalid character 'o' in literal null (expecting 'u')`},
+		{query: RQLQuery{Name: "q", Type: RQLNetwork}, url: "/search", answer: []byte("not_json"),
+			error: `error unmarshaling RQL query "q" result: invalid character 'o' in literal null (expecting 'u')`},
+		{query: RQLQuery{Name: "q", Type: RQLConfig}, url: "/search/config",
+			answer: []byte(`{"data":{"totalRows":3,"items":[{"accountName":"a"}]}}`),
+			result: &RQLResult{Name: "q", Count: 3}},
+		{query: RQLQuery{Name: "q", Type: RQLConfig, GroupBy: "accountName"}, url: "/search/config",
+			answer: []byte(`{"data":{"totalRows":3,"items":[{"accountName":"a"},{"accountName":"a"},{}]}}`),
+			result: &RQLResult{Name: "q", Count: 3, Groups: map[string]int{"a": 2, "none": 1}}},
+		{query: RQLQuery{Name: "q", Type: RQLConfig, GroupBy: "accountName"}, url: "/search/config",
+			answer: []byte(`{"data":{"totalRows":20000,"items":[{"accountName":"a"}]}}`),
+			result: &RQLResult{Name: "q", Count: 20000, Groups: map[string]int{"a": 1}, Truncated: true}},
+		{query: RQLQuery{Name: "q", Type: RQLNetwork}, url: "/search",
+			answer: []byte(`{"data":{"nodes":[{"id":1},{"id":2}]}}`),
+			result: &RQLResult{Name: "q", Count: 2}},
+	}
+
+	// start tests
+	p := &Prisma{}
+
+	for i, x := range testAPIRequestsDataset {
+		p.api = &mockClient{t: t, url: x.url, method: "POST", err: x.serverErr, answer: x.answer}
+		result, err := p.RunRQLQuery(x.query)
+		if x.error != "" {
+			assert.EqualError(t, err, x.error, "Test case %d error check failed", i)
+		} else {
+			assert.NoError(t, err, "Test case %d error check failed", i)
+		}
+		assert.Equal(t, x.result, result, "Test case %d result check failed", i)
+	}
+}
+
+func TestPrisma_RunRQLQueryPages(t *testing.T) {
+	const firstPage = `POST /search/config {"limit":10000,"query":"q","timeRange":{"type":"to_now","value":"epoch"}}`
+	const secondPage = `POST /search/config/page {"limit":10000,"pageToken":"next"}`
+	p := &Prisma{api: &mockRoutes{t: t, routes: map[string]mockResponse{
+		firstPage:  {answer: []byte(`{"data":{"totalRows":3,"items":[{"accountName":"a"}],"nextPageToken":"next"}}`)},
+		secondPage: {answer: []byte(`{"data":{"items":[{"accountName":"a"},{"accountName":"b"}]}}`)},
+	}}}
+	result, err := p.RunRQLQuery(RQLQuery{Name: "n", Type: RQLConfig, Query: "q", GroupBy: "accountName"})
+	assert.NoError(t, err)
+	assert.Equal(t, &RQLResult{Name: "n", Count: 3, Groups: map[string]int{"a": 2, "b": 1}}, result)
+
+	p.api = &mockRoutes{t: t, routes: map[string]mockResponse{
+		`POST /search/event {"limit":10000,"query":"q","timeRange":{"type":"relative","value":{"amount":60,"unit":"minute"}}}`: {
+			answer: []byte(`{"data":{"totalRows":3,"items":[{"accountName":"a"}],"nextPageToken":"next"}}`)},
+	}}
+	result, err = p.RunRQLQuery(RQLQuery{Name: "n", Type: RQLEvent, Query: "q", GroupBy: "accountName", Period: time.Hour})
+	assert.NoError(t, err)
+	assert.Equal(t, &RQLResult{Name: "n", Count: 3, Groups: map[string]int{"a": 1}, Truncated: true}, result,
+		"Event search results are not paged")
+
+	p.api = &mockRoutes{t: t, routes: map[string]mockResponse{
+		`POST /search/config {"limit":1,"query":"q","timeRange":{"type":"to_now","value":"epoch"}}`: {
+			answer: []byte(`{"data":{"totalRows":3,"items":[{"accountName":"a"}],"nextPageToken":"next"}}`)},
+	}}
+	result, err = p.RunRQLQuery(RQLQuery{Name: "n", Type: RQLConfig, Query: "q"})
+	assert.NoError(t, err)
+	assert.Equal(t, &RQLResult{Name: "n", Count: 3}, result, "Only total rows are requested without group by")
+}
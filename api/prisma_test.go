@@ -17,6 +17,7 @@ package api
 import (
 	"fmt"
 	"io"
+	"net"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -72,6 +73,42 @@ func TestPrisma_GetAPIHealthStatus(t *testing.T) {
 	}
 }
 
+func TestPrisma_GetAPIHealth(t *testing.T) {
+	var testAPIRequestsDataset = []struct {
+		err    error
+		health APIHealth
+		status int
+	}{
+		{health: APIHealth{StatusCode: 200}, status: 1},
+		{err: fmt.Errorf("error getting auth token: %w", &APIError{StatusCode: 401}),
+			health: APIHealth{StatusCode: 401, ErrorClass: HealthErrorAuth}, status: 1},
+		{err: &APIError{StatusCode: 429}, health: APIHealth{StatusCode: 429, ErrorClass: HealthErrorRateLimit}, status: 1},
+		{err: &APIError{StatusCode: 400}, health: APIHealth{StatusCode: 400, ErrorClass: HealthErrorClient}, status: 1},
+		{err: &APIError{StatusCode: 503}, health: APIHealth{StatusCode: 503, ErrorClass: HealthErrorServer}},
+		{err: fmt.Errorf("error making request: %w", timeoutError{}), health: APIHealth{ErrorClass: HealthErrorTimeout}},
+		{err: fmt.Errorf("error making request: %w", &net.OpError{Op: "dial", Err: fmt.Errorf("connection refused")}),
+			health: APIHealth{ErrorClass: HealthErrorNetwork}},
+		{err: fmt.Errorf("mock problem"), health: APIHealth{ErrorClass: HealthErrorOther}},
+	}
+
+	// start tests
+	p := &Prisma{}
+
+	for i, x := range testAPIRequestsDataset {
+		p.api = &mockClient{t: t, url: "/check", method: "GET", err: x.err}
+		health := p.GetAPIHealth()
+		health.Latency = 0
+		assert.Equal(t, x.health, health, "Test case %d health check failed", i)
+		assert.Equal(t, x.status, p.GetAPIHealthStatus(), "Test case %d status code check failed", i)
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
 type mockClient struct {
 	t      *testing.T
 	method string
@@ -85,3 +122,28 @@ func (m *mockClient) Call(method, url string, _ io.Reader) ([]byte, error) {
 	assert.Equal(m.t, m.method, method)
 	return m.answer, m.err
 }
+
+type mockResponse struct {
+	answer []byte
+	err    error
+}
+
+// mockRoutes answers every request with response registered for its method, url and body,
+// or for its method and url when there is no response for the body
+type mockRoutes struct {
+	t      *testing.T
+	routes map[string]mockResponse
+}
+
+func (m *mockRoutes) Call(method, url string, body io.Reader) ([]byte, error) {
+	if body != nil {
+		data, err := io.ReadAll(body)
+		assert.NoError(m.t, err)
+		if r, ok := m.routes[method+" "+url+" "+string(data)]; ok {
+			return r.answer, r.err
+		}
+	}
+	r, ok := m.routes[method+" "+url]
+	assert.True(m.t, ok, "unexpected request %s %s", method, url)
+	return r.answer, r.err
+}
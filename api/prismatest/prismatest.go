@@ -0,0 +1,236 @@
+// Copyright 2019 Booking.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package prismatest provides fake Prisma API server for tests and local development,
+// which implements login and token renewal flow, health check and compliance posture endpoints,
+// allows registering responses for other endpoints and injecting faults
+package prismatest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultTokenTTL is lifetime of issued tokens, same as real Prisma API one
+const DefaultTokenTTL = time.Minute * 10
+
+// DefaultCompliancePosture is /compliance/posture response of new server
+const DefaultCompliancePosture = `{"complianceDetails":[{"id":"1","name":"CIS","assignedPolicies":10,` +
+	`"passedResources":90,"failedResources":10,"totalResources":100}]}`
+
+// Fault describes failure injected into server responses
+type Fault struct {
+	// delay before response is sent
+	Latency time.Duration
+	// HTTP status code of response, fault with zero status code only adds latency
+	StatusCode int
+	// Retry-After response header value in seconds, not set when zero
+	RetryAfter int
+	// number of requests fault applies to, unlimited when zero
+	Count int
+}
+
+// response is registered answer of the endpoint
+type response struct {
+	statusCode int
+	body       string
+}
+
+// Server is fake Prisma API server, use URL field as API URL for the client
+type Server struct {
+	*httptest.Server
+	username string
+	password string
+
+	mu        sync.Mutex
+	tokenTTL  time.Duration
+	tokens    map[string]time.Time
+	issued    int
+	responses map[string]response
+	faults    map[string]*Fault
+	requests  map[string]int
+}
+
+// NewServer starts fake Prisma API server accepting given credentials, it should be closed after use
+func NewServer(username, password string) *Server {
+	s := &Server{
+		username:  username,
+		password:  password,
+		tokenTTL:  DefaultTokenTTL,
+		tokens:    map[string]time.Time{},
+		responses: map[string]response{},
+		faults:    map[string]*Fault{},
+		requests:  map[string]int{},
+	}
+	s.SetResponse("GET", "/check", http.StatusOK, `{}`)
+	s.SetResponse("GET", "/compliance/posture", http.StatusOK, DefaultCompliancePosture)
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// SetResponse registers response for requests with given method and URL path, query is ignored
+func (s *Server) SetResponse(method, path string, statusCode int, body string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.responses[method+" "+path] = response{statusCode: statusCode, body: body}
+}
+
+// InjectFault makes requests with given URL path fail, empty path affects all endpoints including login;
+// fault registered for the path takes precedence over fault for all endpoints
+func (s *Server) InjectFault(path string, fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults[path] = &fault
+}
+
+// ClearFaults removes all injected faults
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = map[string]*Fault{}
+}
+
+// SetTokenTTL changes lifetime of tokens issued after the call
+func (s *Server) SetTokenTTL(ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokenTTL = ttl
+}
+
+// ExpireTokens invalidates all issued tokens, so client has to log in again
+func (s *Server) ExpireTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = map[string]time.Time{}
+}
+
+// Requests returns number of requests received for given URL path, including failed ones
+func (s *Server) Requests(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[path]
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests[r.URL.Path]++
+	fault := s.takeFault(r.URL.Path)
+	s.mu.Unlock()
+
+	if fault.Latency > 0 {
+		select {
+		case <-time.After(fault.Latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if fault.StatusCode != 0 {
+		if fault.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(fault.RetryAfter))
+		}
+		writeJSON(w, fault.StatusCode, fmt.Sprintf(`{"message":"injected fault %d"}`, fault.StatusCode))
+		return
+	}
+
+	switch r.URL.Path {
+	case "/login":
+		s.login(w, r)
+		return
+	case "/auth_token/extend":
+		s.extend(w, r)
+		return
+	}
+	if !s.authorized(r) {
+		writeJSON(w, http.StatusUnauthorized, `{"message":"invalid or expired token"}`)
+		return
+	}
+	s.mu.Lock()
+	resp, ok := s.responses[r.Method+" "+r.URL.Path]
+	s.mu.Unlock()
+	if !ok {
+		writeJSON(w, http.StatusNotFound, `{"message":"not found"}`)
+		return
+	}
+	writeJSON(w, resp.statusCode, resp.body)
+}
+
+// takeFault returns fault applicable to given path, decreasing its remaining count; must be called with mu held
+func (s *Server) takeFault(path string) Fault {
+	key := path
+	if _, ok := s.faults[key]; !ok {
+		key = ""
+	}
+	fault, ok := s.faults[key]
+	if !ok {
+		return Fault{}
+	}
+	if fault.Count > 0 {
+		fault.Count--
+		if fault.Count == 0 {
+			delete(s.faults, key)
+		}
+	}
+	return *fault
+}
+
+func (s *Server) login(w http.ResponseWriter, r *http.Request) {
+	var credentials struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if r.Method != http.MethodPost || json.NewDecoder(r.Body).Decode(&credentials) != nil {
+		writeJSON(w, http.StatusBadRequest, `{"message":"bad login request"}`)
+		return
+	}
+	if credentials.Username != s.username || credentials.Password != s.password {
+		writeJSON(w, http.StatusUnauthorized, `{"message":"login_needed"}`)
+		return
+	}
+	writeJSON(w, http.StatusOK, fmt.Sprintf(`{"token":%q}`, s.issueToken()))
+}
+
+func (s *Server) extend(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		writeJSON(w, http.StatusUnauthorized, `{"message":"invalid or expired token"}`)
+		return
+	}
+	writeJSON(w, http.StatusOK, fmt.Sprintf(`{"token":%q}`, s.issueToken()))
+}
+
+func (s *Server) issueToken() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.issued++
+	token := fmt.Sprintf("token-%d", s.issued)
+	s.tokens[token] = time.Now().Add(s.tokenTTL)
+	return token
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	expires, ok := s.tokens[r.Header.Get("x-redlock-auth")]
+	return ok && time.Now().Before(expires)
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, body string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_, _ = w.Write([]byte(body))
+}
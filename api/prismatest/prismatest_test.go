@@ -0,0 +1,95 @@
+// Copyright 2019 Booking.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prismatest_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bookingcom/cloudsec-metrics/api"
+	"github.com/bookingcom/cloudsec-metrics/api/prismatest"
+)
+
+func TestServer(t *testing.T) {
+	s := prismatest.NewServer("key", "secret")
+	defer s.Close()
+	p := api.NewPrisma("key", "secret", s.URL)
+	p.SetRetryPolicy(0, 0)
+
+	info, err := p.GatherComplianceInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, []api.ComplianceInfo{{Name: "CIS", PoliciesCount: 10,
+		PassedAssetsCount: 90, FailedAssetsCount: 10, TotalAssetsCount: 100}}, info)
+	assert.Equal(t, api.APIHealth{StatusCode: http.StatusOK}, withoutLatency(p.GetAPIHealth()))
+	assert.Equal(t, 1, s.Requests("/login"), "Token is reused between calls")
+
+	s.SetResponse("GET", "/compliance/posture", http.StatusOK, `{"complianceDetails":[]}`)
+	info, err = p.GatherComplianceInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, []api.ComplianceInfo{}, info)
+
+	bad := api.NewPrisma("key", "wrong", s.URL)
+	bad.SetRetryPolicy(0, 0)
+	assert.Equal(t, api.APIHealth{StatusCode: http.StatusUnauthorized, ErrorClass: api.HealthErrorAuth},
+		withoutLatency(bad.GetAPIHealth()), "Wrong credentials are rejected")
+
+	s.ExpireTokens()
+	assert.Equal(t, api.APIHealth{StatusCode: http.StatusOK}, withoutLatency(p.GetAPIHealth()),
+		"Request with expired token is repeated after new login")
+	assert.Equal(t, 3, s.Requests("/login"), "Wrong credentials login and new login after expiration are counted")
+}
+
+func TestServer_InjectFault(t *testing.T) {
+	s := prismatest.NewServer("key", "secret")
+	defer s.Close()
+	p := api.NewPrisma("key", "secret", s.URL)
+	p.SetRetryPolicy(0, 0)
+
+	s.InjectFault("/check", prismatest.Fault{StatusCode: http.StatusServiceUnavailable, Count: 1})
+	assert.Equal(t, api.APIHealth{StatusCode: http.StatusServiceUnavailable, ErrorClass: api.HealthErrorServer},
+		withoutLatency(p.GetAPIHealth()))
+	assert.Equal(t, api.APIHealth{StatusCode: http.StatusOK}, withoutLatency(p.GetAPIHealth()),
+		"Fault is removed after given number of requests")
+
+	s.InjectFault("", prismatest.Fault{StatusCode: http.StatusTooManyRequests})
+	s.InjectFault("/check", prismatest.Fault{Latency: time.Millisecond * 50})
+	h := p.GetAPIHealth()
+	assert.Equal(t, http.StatusOK, h.StatusCode, "Path fault takes precedence over fault for all endpoints")
+	assert.GreaterOrEqual(t, h.Latency, time.Millisecond*50)
+	_, err := p.GatherComplianceInfo()
+	assert.EqualError(t, err, `error requesting assets information: 429 Too Many Requests, `+
+		`response body: "{\"message\":\"injected fault 429\"}"`)
+
+	s.ClearFaults()
+	_, err = p.GatherComplianceInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, s.Requests("/compliance/posture"))
+
+	// retried call succeeds once the fault is gone
+	s.InjectFault("/compliance/posture", prismatest.Fault{StatusCode: http.StatusBadGateway, Count: 1})
+	p.SetRetryPolicy(1, 0)
+	_, err = p.GatherComplianceInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, 4, s.Requests("/compliance/posture"))
+	assert.Equal(t, api.CallStats{Calls: 3, Retries: 1, RateLimited: 1, Failures: 1}, p.GetCallStats())
+}
+
+func withoutLatency(h api.APIHealth) api.APIHealth {
+	h.Latency = 0
+	return h
+}
@@ -6,9 +6,11 @@ require (
 	cloud.google.com/go/securitycenter v1.28.0
 	github.com/jessevdk/go-flags v1.5.0
 	github.com/jtaczanowski/go-graphite-client v1.1.0
-	github.com/paskal/go-prisma v1.0.0
 	github.com/stretchr/testify v1.9.0
+	golang.org/x/time v0.5.0
 	google.golang.org/api v0.170.0
+	google.golang.org/grpc v1.62.1
+	google.golang.org/protobuf v1.33.0
 )
 
 require (
@@ -39,12 +41,9 @@ require (
 	golang.org/x/sync v0.6.0 // indirect
 	golang.org/x/sys v0.18.0 // indirect
 	golang.org/x/text v0.14.0 // indirect
-	golang.org/x/time v0.5.0 // indirect
 	google.golang.org/appengine v1.6.8 // indirect
 	google.golang.org/genproto v0.0.0-20240213162025-012b6fc9bca9 // indirect
 	google.golang.org/genproto/googleapis/api v0.0.0-20240311132316-a219d84964c2 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20240311132316-a219d84964c2 // indirect
-	google.golang.org/grpc v1.62.1 // indirect
-	google.golang.org/protobuf v1.33.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -43,6 +43,340 @@ func GenerateSSCSourcesDelay(prefix string, delay map[string]time.Duration) map[
 	return metrics
 }
 
+// GeneratePrismaAlertAging returns metrics from given alerts aging info
+func GeneratePrismaAlertAging(prefix string, info []api.AlertAgingInfo) map[string]float64 {
+	metrics := map[string]float64{}
+	for _, entry := range info {
+		metricPrefix := prefix + escapeMetricName(entry.Severity)
+		metrics[metricPrefix+".open_age.lt_1d"] = float64(entry.OpenLess1Day)
+		metrics[metricPrefix+".open_age.1d_7d"] = float64(entry.Open1To7Days)
+		metrics[metricPrefix+".open_age.7d_30d"] = float64(entry.Open7To30Days)
+		metrics[metricPrefix+".open_age.gt_30d"] = float64(entry.OpenOver30Days)
+		metrics[metricPrefix+".resolved_total"] = float64(entry.ResolvedCount)
+		if entry.ResolvedCount > 0 {
+			metrics[metricPrefix+".time_to_resolve.mean_seconds"] = entry.MeanTimeToResolve.Seconds()
+			metrics[metricPrefix+".time_to_resolve.p50_seconds"] = entry.P50TimeToResolve.Seconds()
+			metrics[metricPrefix+".time_to_resolve.p90_seconds"] = entry.P90TimeToResolve.Seconds()
+			metrics[metricPrefix+".time_to_resolve.p99_seconds"] = entry.P99TimeToResolve.Seconds()
+		}
+	}
+	return metrics
+}
+
+// GeneratePrismaPolicyInventory returns metrics from given policy inventory
+func GeneratePrismaPolicyInventory(prefix string, inventory *api.PolicyInventory) map[string]float64 {
+	metrics := map[string]float64{}
+	if inventory == nil {
+		return metrics
+	}
+	addCounts := func(metricPrefix string, counts api.PolicyCounts) {
+		metrics[metricPrefix+".enabled"] = float64(counts.Enabled)
+		metrics[metricPrefix+".disabled"] = float64(counts.Disabled)
+	}
+	addCounts(prefix+"total", inventory.Total)
+	addCounts(prefix+"custom", inventory.Custom)
+	addCounts(prefix+"system", inventory.System)
+	for severity, counts := range inventory.BySeverity {
+		addCounts(prefix+"severity."+escapeMetricName(severity), counts)
+	}
+	for policyType, counts := range inventory.ByType {
+		addCounts(prefix+"type."+escapeMetricName(policyType), counts)
+	}
+	return metrics
+}
+
+// GeneratePrismaCloudAccounts returns metrics from given cloud accounts info, keyed by account name and ID
+// as names are not unique; status counts are not reported for accounts which status failed to be requested
+func GeneratePrismaCloudAccounts(prefix string, accounts []api.CloudAccountInfo) map[string]float64 {
+	metrics := map[string]float64{}
+	for _, entry := range accounts {
+		metricPrefix := prefix + escapeMetricName(entry.CloudType) + "." + escapeMetricName(entry.Name) + "." +
+			escapeMetricName(entry.ID)
+		metrics[metricPrefix+".error"] = 0
+		if entry.StatusError != nil {
+			metrics[metricPrefix+".error"] = 1
+		}
+		metrics[metricPrefix+".enabled"] = 0
+		if entry.Enabled {
+			metrics[metricPrefix+".enabled"] = 1
+		}
+		if entry.IngestionDelay != 0 {
+			metrics[metricPrefix+".ingestion_delay_seconds"] = entry.IngestionDelay.Seconds()
+		}
+		if entry.StatusError == nil {
+			metrics[metricPrefix+".status_errors"] = float64(entry.StatusErrors)
+			metrics[metricPrefix+".status_warnings"] = float64(entry.StatusWarnings)
+		}
+	}
+	return metrics
+}
+
+// GeneratePrismaAssetInventory returns metrics from given asset inventory,
+// per cloud type, service and resource type ones could be summed up with Graphite wildcards
+func GeneratePrismaAssetInventory(prefix string, inventory *api.AssetInventory) map[string]float64 {
+	metrics := map[string]float64{}
+	if inventory == nil {
+		return metrics
+	}
+	addCounts := func(metricPrefix string, counts api.AssetCounts) {
+		metrics[metricPrefix+".assets_passed"] = float64(counts.PassedAssetsCount)
+		metrics[metricPrefix+".assets_failed"] = float64(counts.FailedAssetsCount)
+		metrics[metricPrefix+".assets_total"] = float64(counts.TotalAssetsCount)
+	}
+	addCounts(prefix+"total", inventory.Summary)
+	for _, entry := range inventory.Groups {
+		addCounts(prefix+escapeMetricName(entry.CloudType)+"."+escapeMetricName(entry.Service)+"."+
+			escapeMetricName(entry.ResourceType), entry.AssetCounts)
+	}
+	return metrics
+}
+
+// GeneratePrismaRQLResults returns metrics from given RQL queries results
+func GeneratePrismaRQLResults(prefix string, results map[string]*api.RQLResult) map[string]float64 {
+	metrics := map[string]float64{}
+	for name, result := range results {
+		metricPrefix := prefix + escapeMetricName(name)
+		metrics[metricPrefix+".count"] = float64(result.Count)
+		metrics[metricPrefix+".truncated"] = 0
+		if result.Truncated {
+			metrics[metricPrefix+".truncated"] = 1
+		}
+		for group, count := range result.Groups {
+			metrics[metricPrefix+".group."+escapeMetricName(group)] = float64(count)
+		}
+	}
+	return metrics
+}
+
+// GeneratePrismaAccessHygiene returns metrics from given access keys and users information, key expiration
+// is reported per key name and ID as names are not unique, and for the key used by this exporter
+// additionally under own_key name
+func GeneratePrismaAccessHygiene(prefix string, info *api.AccessHygieneInfo) map[string]float64 {
+	metrics := map[string]float64{}
+	if info == nil {
+		return metrics
+	}
+	for status, count := range info.KeysByStatus {
+		metrics[prefix+"keys.status."+escapeMetricName(status)] = float64(count)
+	}
+	for _, key := range info.Keys {
+		if key.ExpiresIn == nil {
+			continue
+		}
+		days := key.ExpiresIn.Hours() / 24
+		metrics[prefix+"keys.expiry_days."+escapeMetricName(key.Name)+"."+escapeMetricName(key.ID)] = days
+		if key.Own {
+			metrics[prefix+"own_key.expiry_days"] = days
+		}
+	}
+	for role, count := range info.ActiveUsersByRole {
+		metrics[prefix+"users.active."+escapeMetricName(role)] = float64(count)
+	}
+	return metrics
+}
+
+// GenerateComputeDefenders returns metrics from given defenders info
+func GenerateComputeDefenders(prefix string, info *api.DefendersInfo) map[string]float64 {
+	metrics := map[string]float64{}
+	if info == nil {
+		return metrics
+	}
+	for defenderType, count := range info.Connected {
+		metrics[prefix+"defenders.connected."+escapeMetricName(defenderType)] = float64(count)
+	}
+	for defenderType, count := range info.Disconnected {
+		metrics[prefix+"defenders.disconnected."+escapeMetricName(defenderType)] = float64(count)
+	}
+	return metrics
+}
+
+// GenerateComputeVulnerabilities returns metrics from given vulnerabilities info
+func GenerateComputeVulnerabilities(prefix string, info *api.VulnerabilityInfo) map[string]float64 {
+	metrics := map[string]float64{}
+	if info == nil {
+		return metrics
+	}
+	addCounts := func(metricPrefix string, counts api.VulnerabilityCounts) {
+		metrics[metricPrefix+".critical"] = float64(counts.Critical)
+		metrics[metricPrefix+".high"] = float64(counts.High)
+		metrics[metricPrefix+".medium"] = float64(counts.Medium)
+		metrics[metricPrefix+".low"] = float64(counts.Low)
+	}
+	addCounts(prefix+"vulnerabilities.hosts", info.Hosts)
+	addCounts(prefix+"vulnerabilities.images", info.Images)
+	addCounts(prefix+"vulnerabilities.containers", info.Containers)
+	return metrics
+}
+
+// GeneratePrismaLicense returns metrics from given license info
+func GeneratePrismaLicense(prefix string, info *api.LicenseInfo) map[string]float64 {
+	metrics := map[string]float64{}
+	if info == nil {
+		return metrics
+	}
+	metrics[prefix+"credits.licensed"] = float64(info.LicensedCredits)
+	metrics[prefix+"credits.consumed"] = float64(info.ConsumedCredits)
+	for cloudType, usage := range info.ByCloudType {
+		metricPrefix := prefix + escapeMetricName(cloudType)
+		metrics[metricPrefix+".credits"] = float64(usage.Credits)
+		for resourceType, count := range usage.ResourceCounts {
+			metrics[metricPrefix+".resources."+escapeMetricName(resourceType)] = float64(count)
+		}
+	}
+	return metrics
+}
+
+// GeneratePrismaAPIHealth returns metrics from given Prisma API health check result,
+// with every error class reported as 1 when it happened and 0 otherwise
+func GeneratePrismaAPIHealth(prefix string, health *api.APIHealth) map[string]float64 {
+	metrics := map[string]float64{}
+	if health == nil {
+		return metrics
+	}
+	metrics[prefix+"latency_seconds"] = health.Latency.Seconds()
+	metrics[prefix+"status_class"] = float64(health.StatusCode / 100)
+	for _, class := range api.HealthErrorClasses() {
+		metrics[prefix+"errors."+class] = 0
+	}
+	if health.ErrorClass != "" {
+		metrics[prefix+"errors."+health.ErrorClass] = 1
+	}
+	return metrics
+}
+
+// GeneratePrismaCallStats returns metrics from given Prisma API calls counters
+func GeneratePrismaCallStats(prefix string, stats *api.CallStats) map[string]float64 {
+	metrics := map[string]float64{}
+	if stats == nil {
+		return metrics
+	}
+	metrics[prefix+"calls_total"] = float64(stats.Calls)
+	metrics[prefix+"retries_total"] = float64(stats.Retries)
+	metrics[prefix+"rate_limited_total"] = float64(stats.RateLimited)
+	metrics[prefix+"failures_total"] = float64(stats.Failures)
+	return metrics
+}
+
+// GenerateSCCSourcesErrors returns 1 for every given source name which is present in errors map, 0 otherwise
+func GenerateSCCSourcesErrors(prefix string, sources map[string]string, errs map[string]error) map[string]float64 {
+	metrics := map[string]float64{}
+	for _, name := range sources {
+		metrics[prefix+escapeMetricName(name)+".error"] = 0
+		if errs[name] != nil {
+			metrics[prefix+escapeMetricName(name)+".error"] = 1
+		}
+	}
+	return metrics
+}
+
+// GenerateSCCFindingsCounts returns metrics from given SCC active findings counts
+func GenerateSCCFindingsCounts(prefix string, counts []api.SCCFindingsCount) map[string]float64 {
+	metrics := map[string]float64{}
+	for _, c := range counts {
+		metrics[prefix+escapeMetricName(c.Source)+"."+escapeMetricName(c.Category)+"."+escapeMetricName(c.Severity)] = float64(c.Count)
+	}
+	return metrics
+}
+
+// GenerateSCCFindingsLifecycle returns metrics from given SCC findings lifecycle statistics
+func GenerateSCCFindingsLifecycle(prefix string, lifecycle []api.SCCFindingsLifecycle) map[string]float64 {
+	metrics := map[string]float64{}
+	for _, entry := range lifecycle {
+		metricPrefix := prefix + escapeMetricName(entry.Source) + "." + escapeMetricName(entry.Severity)
+		metrics[metricPrefix+".created_total"] = float64(entry.Created)
+		metrics[metricPrefix+".resolved_total"] = float64(entry.Resolved)
+		metrics[metricPrefix+".open_age.lt_1d"] = float64(entry.OpenLess1Day)
+		metrics[metricPrefix+".open_age.1d_7d"] = float64(entry.Open1To7Days)
+		metrics[metricPrefix+".open_age.7d_30d"] = float64(entry.Open7To30Days)
+		metrics[metricPrefix+".open_age.gt_30d"] = float64(entry.OpenOver30Days)
+		if entry.Resolved > 0 {
+			metrics[metricPrefix+".time_to_remediate.mean_seconds"] = entry.MeanTimeToRemediate.Seconds()
+			metrics[metricPrefix+".time_to_remediate.p50_seconds"] = entry.P50TimeToRemediate.Seconds()
+			metrics[metricPrefix+".time_to_remediate.p90_seconds"] = entry.P90TimeToRemediate.Seconds()
+			metrics[metricPrefix+".time_to_remediate.p99_seconds"] = entry.P99TimeToRemediate.Seconds()
+		}
+	}
+	return metrics
+}
+
+// GenerateSCCAssetCounts returns metrics from given SCC assets counts per project and resource type,
+// along with total assets count per resource type
+func GenerateSCCAssetCounts(prefix string, counts []api.SCCAssetCount) map[string]float64 {
+	metrics := map[string]float64{}
+	for _, c := range counts {
+		resourceType := escapeMetricName(c.ResourceType)
+		metrics[prefix+"project."+escapeMetricName(c.Project)+"."+resourceType] = float64(c.Count)
+		metrics[prefix+"type."+resourceType] += float64(c.Count)
+	}
+	return metrics
+}
+
+// GenerateSCCNotificationsHealth returns metrics from given SCC notification configs health,
+// latest event age is reported only for configs which passed the flow check, and validity
+// is not reported for configs with valid topic which filter failed to be checked
+func GenerateSCCNotificationsHealth(prefix string, health []api.SCCNotificationHealth) map[string]float64 {
+	metrics := map[string]float64{}
+	if health == nil {
+		return metrics
+	}
+	metrics[prefix+"configs_total"] = float64(len(health))
+	for _, h := range health {
+		metricPrefix := prefix + "config." + escapeMetricName(h.ID)
+		if h.CheckError == nil || !h.ValidTopic {
+			metrics[metricPrefix+".valid"] = 0
+			if h.ValidTopic && h.ValidFilter {
+				metrics[metricPrefix+".valid"] = 1
+			}
+		}
+		metrics[metricPrefix+".healthy"] = 0
+		if h.Healthy {
+			metrics[metricPrefix+".healthy"] = 1
+		}
+		if h.FlowChecked {
+			metrics[metricPrefix+".latest_event_seconds"] = h.LatestEvent.Seconds()
+		}
+	}
+	return metrics
+}
+
+// GenerateSCCMuteInfo returns metrics from given SCC muted findings and mute configs information
+func GenerateSCCMuteInfo(prefix string, info *api.SCCMuteInfo) map[string]float64 {
+	metrics := map[string]float64{}
+	if info == nil {
+		return metrics
+	}
+	metrics[prefix+"configs_total"] = float64(len(info.Configs))
+	for _, config := range info.Configs {
+		metricPrefix := prefix + "config." + escapeMetricName(config.ID)
+		metrics[metricPrefix+".muted"] = 0
+		metrics[metricPrefix+".updated_timestamp"] = float64(config.UpdateTime.Unix())
+	}
+	for id, count := range info.MutedByConfig {
+		metrics[prefix+"config."+escapeMetricName(id)+".muted"] = float64(count)
+	}
+	metrics[prefix+"manually_muted"] = float64(info.MutedManually)
+	for category, count := range info.MutedByCategory {
+		metrics[prefix+"category."+escapeMetricName(category)] = float64(count)
+	}
+	return metrics
+}
+
+// GenerateSCCOwnersFindings returns metrics from given SCC active high and critical findings counts per project and folder
+func GenerateSCCOwnersFindings(prefix string, findings *api.SCCOwnersFindings) map[string]float64 {
+	metrics := map[string]float64{}
+	if findings == nil {
+		return metrics
+	}
+	for kind, owners := range map[string]map[string]*api.SCCSeverityCounts{"project.": findings.Projects, "folder.": findings.Folders} {
+		for name, counts := range owners {
+			metricPrefix := prefix + kind + escapeMetricName(name)
+			metrics[metricPrefix+".high"] = float64(counts.High)
+			metrics[metricPrefix+".critical"] = float64(counts.Critical)
+		}
+	}
+	return metrics
+}
+
 func escapeMetricName(name string) string {
 	result := ""
 	for _, c := range name {
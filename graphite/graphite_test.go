@@ -15,6 +15,8 @@
 package graphite
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -48,3 +50,372 @@ func TestGraphite(t *testing.T) {
 		"Single metric send to empty Graphite should do nothing and return no errors")
 	assert.Equal(t, "_test_of_metric", escapeMetricName("(test)of/metric"))
 }
+
+func TestGeneratePrismaAlertAging(t *testing.T) {
+	assert.Equal(t, map[string]float64{}, GeneratePrismaAlertAging("", nil),
+		"Run with no metrics should return nil")
+	assert.Equal(t,
+		map[string]float64{
+			"alerts.high.open_age.lt_1d":              1,
+			"alerts.high.open_age.1d_7d":              2,
+			"alerts.high.open_age.7d_30d":             3,
+			"alerts.high.open_age.gt_30d":             4,
+			"alerts.high.resolved_total":              0,
+			"alerts.low.open_age.lt_1d":               0,
+			"alerts.low.open_age.1d_7d":               0,
+			"alerts.low.open_age.7d_30d":              0,
+			"alerts.low.open_age.gt_30d":              0,
+			"alerts.low.resolved_total":               2,
+			"alerts.low.time_to_resolve.mean_seconds": 90,
+			"alerts.low.time_to_resolve.p50_seconds":  60,
+			"alerts.low.time_to_resolve.p90_seconds":  120,
+			"alerts.low.time_to_resolve.p99_seconds":  120,
+		},
+		GeneratePrismaAlertAging("alerts.", []api.AlertAgingInfo{
+			{Severity: "high", OpenLess1Day: 1, Open1To7Days: 2, Open7To30Days: 3, OpenOver30Days: 4},
+			{Severity: "low", ResolvedCount: 2, MeanTimeToResolve: time.Second * 90,
+				P50TimeToResolve: time.Minute, P90TimeToResolve: time.Minute * 2, P99TimeToResolve: time.Minute * 2},
+		}),
+		"Time to resolve is reported only for severities with resolved alerts")
+}
+
+func TestGeneratePrismaPolicyInventory(t *testing.T) {
+	assert.Equal(t, map[string]float64{}, GeneratePrismaPolicyInventory("", nil),
+		"Run with no metrics should return nil")
+	assert.Equal(t,
+		map[string]float64{
+			"policies.total.enabled":             3,
+			"policies.total.disabled":            1,
+			"policies.custom.enabled":            1,
+			"policies.custom.disabled":           0,
+			"policies.system.enabled":            2,
+			"policies.system.disabled":           1,
+			"policies.severity.high.enabled":     3,
+			"policies.severity.high.disabled":    1,
+			"policies.type.audit_event.enabled":  3,
+			"policies.type.audit_event.disabled": 1,
+		},
+		GeneratePrismaPolicyInventory("policies.", &api.PolicyInventory{
+			Total:      api.PolicyCounts{Enabled: 3, Disabled: 1},
+			Custom:     api.PolicyCounts{Enabled: 1},
+			System:     api.PolicyCounts{Enabled: 2, Disabled: 1},
+			BySeverity: map[string]api.PolicyCounts{"high": {Enabled: 3, Disabled: 1}},
+			ByType:     map[string]api.PolicyCounts{"audit_event": {Enabled: 3, Disabled: 1}},
+		}),
+		"Every counter is reported as enabled and disabled pair")
+}
+
+func TestGeneratePrismaCloudAccounts(t *testing.T) {
+	assert.Equal(t, map[string]float64{}, GeneratePrismaCloudAccounts("", nil),
+		"Run with no metrics should return nil")
+	assert.Equal(t,
+		map[string]float64{
+			"accounts.aws.prod_account.123.error":                    0,
+			"accounts.aws.prod_account.123.enabled":                  1,
+			"accounts.aws.prod_account.123.ingestion_delay_seconds":  60,
+			"accounts.aws.prod_account.123.status_errors":            1,
+			"accounts.aws.prod_account.123.status_warnings":          2,
+			"accounts.aws.prod_account.456.error":                    1,
+			"accounts.aws.prod_account.456.enabled":                  1,
+			"accounts.gcp.never_ingested.my-project.error":           0,
+			"accounts.gcp.never_ingested.my-project.enabled":         0,
+			"accounts.gcp.never_ingested.my-project.status_errors":   0,
+			"accounts.gcp.never_ingested.my-project.status_warnings": 0,
+		},
+		GeneratePrismaCloudAccounts("accounts.", []api.CloudAccountInfo{
+			{ID: "123", Name: "prod account", CloudType: "aws", Enabled: true, IngestionDelay: time.Minute, StatusErrors: 1, StatusWarnings: 2},
+			{ID: "456", Name: "prod account", CloudType: "aws", Enabled: true, StatusError: fmt.Errorf("mock error")},
+			{ID: "my-project", Name: "never ingested", CloudType: "gcp"},
+		}),
+		"Ingestion delay is not reported for accounts which were never ingested, status for failed ones")
+}
+
+func TestGeneratePrismaAssetInventory(t *testing.T) {
+	assert.Equal(t, map[string]float64{}, GeneratePrismaAssetInventory("", nil),
+		"Run with no metrics should return nil")
+	assert.Equal(t,
+		map[string]float64{
+			"inventory.total.assets_passed":                       1,
+			"inventory.total.assets_failed":                       2,
+			"inventory.total.assets_total":                        3,
+			"inventory.aws.Amazon_EC2.EC2_Instance.assets_passed": 1,
+			"inventory.aws.Amazon_EC2.EC2_Instance.assets_failed": 2,
+			"inventory.aws.Amazon_EC2.EC2_Instance.assets_total":  3,
+		},
+		GeneratePrismaAssetInventory("inventory.", &api.AssetInventory{
+			Summary: api.AssetCounts{PassedAssetsCount: 1, FailedAssetsCount: 2, TotalAssetsCount: 3},
+			Groups: []api.AssetGroupInfo{{CloudType: "aws", Service: "Amazon EC2", ResourceType: "EC2 Instance",
+				AssetCounts: api.AssetCounts{PassedAssetsCount: 1, FailedAssetsCount: 2, TotalAssetsCount: 3}}},
+		}),
+		"Summary and every group are reported")
+}
+
+func TestGeneratePrismaRQLResults(t *testing.T) {
+	assert.Equal(t, map[string]float64{}, GeneratePrismaRQLResults("", nil),
+		"Run with no metrics should return nil")
+	assert.Equal(t,
+		map[string]float64{
+			"rql.public_buckets.count":            3,
+			"rql.public_buckets.truncated":        1,
+			"rql.public_buckets.group.my_account": 2,
+			"rql.public_buckets.group.none":       1,
+			"rql.vms.count":                       0,
+			"rql.vms.truncated":                   0,
+		},
+		GeneratePrismaRQLResults("rql.", map[string]*api.RQLResult{
+			"public_buckets": {Name: "public_buckets", Count: 3, Groups: map[string]int{"my.account": 2, "none": 1}, Truncated: true},
+			"vms":            {Name: "vms"},
+		}),
+		"Group counts are reported only for queries with group by")
+}
+
+func TestGeneratePrismaAccessHygiene(t *testing.T) {
+	assert.Equal(t, map[string]float64{}, GeneratePrismaAccessHygiene("", nil),
+		"Run with no metrics should return nil")
+	expiresIn := time.Hour * 36
+	expiresSoon := time.Hour * 12
+	assert.Equal(t,
+		map[string]float64{
+			"access.keys.status.active":         2,
+			"access.keys.status.expired":        1,
+			"access.keys.expiry_days.metrics.1": 1.5,
+			"access.keys.expiry_days.metrics.3": 0.5,
+			"access.own_key.expiry_days":        1.5,
+			"access.users.active.System_Admin":  2,
+		},
+		GeneratePrismaAccessHygiene("access.", &api.AccessHygieneInfo{
+			KeysByStatus: map[string]int{"active": 2, "expired": 1},
+			Keys: []api.AccessKeyInfo{
+				{ID: "1", Name: "metrics", Status: "active", Own: true, ExpiresIn: &expiresIn},
+				{ID: "2", Name: "no expiry", Status: "expired"},
+				{ID: "3", Name: "metrics", Status: "active", ExpiresIn: &expiresSoon}},
+			ActiveUsersByRole: map[string]int{"System Admin": 2},
+		}),
+		"Expiration is reported per key name and ID only for keys with expiration date set")
+}
+
+func TestGenerateCompute(t *testing.T) {
+	assert.Equal(t, map[string]float64{}, GenerateComputeDefenders("", nil),
+		"Run with no metrics should return nil")
+	assert.Equal(t, map[string]float64{}, GenerateComputeVulnerabilities("", nil),
+		"Run with no metrics should return nil")
+	assert.Equal(t,
+		map[string]float64{
+			"compute.defenders.connected.daemonset": 3,
+			"compute.defenders.disconnected.docker": 1,
+		},
+		GenerateComputeDefenders("compute.", &api.DefendersInfo{
+			Connected:    map[string]int{"daemonset": 3},
+			Disconnected: map[string]int{"docker": 1},
+		}))
+	assert.Equal(t,
+		map[string]float64{
+			"compute.vulnerabilities.hosts.critical":      1,
+			"compute.vulnerabilities.hosts.high":          2,
+			"compute.vulnerabilities.hosts.medium":        3,
+			"compute.vulnerabilities.hosts.low":           4,
+			"compute.vulnerabilities.images.critical":     0,
+			"compute.vulnerabilities.images.high":         5,
+			"compute.vulnerabilities.images.medium":       0,
+			"compute.vulnerabilities.images.low":          0,
+			"compute.vulnerabilities.containers.critical": 0,
+			"compute.vulnerabilities.containers.high":     10,
+			"compute.vulnerabilities.containers.medium":   0,
+			"compute.vulnerabilities.containers.low":      0,
+		},
+		GenerateComputeVulnerabilities("compute.", &api.VulnerabilityInfo{
+			Hosts:      api.VulnerabilityCounts{Critical: 1, High: 2, Medium: 3, Low: 4},
+			Images:     api.VulnerabilityCounts{High: 5},
+			Containers: api.VulnerabilityCounts{High: 10},
+		}))
+}
+
+func TestGeneratePrismaLicense(t *testing.T) {
+	assert.Equal(t, map[string]float64{}, GeneratePrismaLicense("", nil),
+		"Run with no metrics should return nil")
+	assert.Equal(t,
+		map[string]float64{
+			"license.credits.licensed":       100,
+			"license.credits.consumed":       12,
+			"license.aws.credits":            12,
+			"license.aws.resources.compute":  10,
+			"license.aws.resources.database": 2,
+		},
+		GeneratePrismaLicense("license.", &api.LicenseInfo{LicensedCredits: 100, ConsumedCredits: 12,
+			ByCloudType: map[string]api.CloudTypeUsage{
+				"aws": {Credits: 12, ResourceCounts: map[string]int{"compute": 10, "database": 2}},
+			}}))
+}
+
+func TestGeneratePrismaAPIHealth(t *testing.T) {
+	assert.Equal(t, map[string]float64{}, GeneratePrismaAPIHealth("", nil),
+		"Run with no metrics should return nil")
+	assert.Equal(t,
+		map[string]float64{
+			"prisma_api.latency_seconds":     0.25,
+			"prisma_api.status_class":        4,
+			"prisma_api.errors.auth":         1,
+			"prisma_api.errors.rate_limit":   0,
+			"prisma_api.errors.timeout":      0,
+			"prisma_api.errors.server_error": 0,
+			"prisma_api.errors.client_error": 0,
+			"prisma_api.errors.network":      0,
+			"prisma_api.errors.other":        0,
+		},
+		GeneratePrismaAPIHealth("prisma_api.", &api.APIHealth{Latency: time.Millisecond * 250, StatusCode: 401,
+			ErrorClass: api.HealthErrorAuth}))
+}
+
+func TestGeneratePrismaCallStats(t *testing.T) {
+	assert.Equal(t, map[string]float64{}, GeneratePrismaCallStats("", nil),
+		"Run with no metrics should return nil")
+	assert.Equal(t,
+		map[string]float64{
+			"prisma_api.calls_total":        4,
+			"prisma_api.retries_total":      3,
+			"prisma_api.rate_limited_total": 2,
+			"prisma_api.failures_total":     1,
+		},
+		GeneratePrismaCallStats("prisma_api.", &api.CallStats{Calls: 4, Retries: 3, RateLimited: 2, Failures: 1}))
+}
+
+func TestGenerateSCCFindingsCounts(t *testing.T) {
+	assert.Equal(t, map[string]float64{}, GenerateSCCFindingsCounts("", nil),
+		"Run with no metrics should return nil")
+	assert.Equal(t,
+		map[string]float64{
+			"scc_findings.Security_Health_Analytics.PUBLIC_BUCKET_ACL.high": 3,
+			"scc_findings.Security_Health_Analytics.OPEN_FIREWALL.critical": 1,
+		},
+		GenerateSCCFindingsCounts("scc_findings.", []api.SCCFindingsCount{
+			{Source: "Security Health Analytics", Category: "PUBLIC_BUCKET_ACL", Severity: "high", Count: 3},
+			{Source: "Security Health Analytics", Category: "OPEN_FIREWALL", Severity: "critical", Count: 1}}))
+}
+
+func TestGenerateSCCOwnersFindings(t *testing.T) {
+	assert.Equal(t, map[string]float64{}, GenerateSCCOwnersFindings("", nil),
+		"Run with no metrics should return nil")
+	assert.Equal(t,
+		map[string]float64{
+			"scc_owners.project.web_prod.high":     2,
+			"scc_owners.project.web_prod.critical": 1,
+			"scc_owners.folder.Web_team.high":      2,
+			"scc_owners.folder.Web_team.critical":  1,
+		},
+		GenerateSCCOwnersFindings("scc_owners.", &api.SCCOwnersFindings{
+			Projects: map[string]*api.SCCSeverityCounts{"web.prod": {High: 2, Critical: 1}},
+			Folders:  map[string]*api.SCCSeverityCounts{"Web team": {High: 2, Critical: 1}}}))
+}
+
+func TestGenerateSCCSourcesErrors(t *testing.T) {
+	assert.Equal(t, map[string]float64{}, GenerateSCCSourcesErrors("", nil, nil),
+		"Run with no metrics should return nil")
+	assert.Equal(t,
+		map[string]float64{"scc_delay.SHA.error": 0, "scc_delay.Forseti_alerts.error": 1},
+		GenerateSCCSourcesErrors("scc_delay.", map[string]string{"sources/1": "SHA", "sources/2": "Forseti alerts"},
+			map[string]error{"Forseti alerts": fmt.Errorf("mock error")}))
+}
+
+func TestGenerateSCCFindingsLifecycle(t *testing.T) {
+	assert.Equal(t, map[string]float64{}, GenerateSCCFindingsLifecycle("", nil),
+		"Run with no metrics should return nil")
+	assert.Equal(t,
+		map[string]float64{
+			"scc_lifecycle.SHA.high.created_total":                  1,
+			"scc_lifecycle.SHA.high.resolved_total":                 2,
+			"scc_lifecycle.SHA.high.open_age.lt_1d":                 1,
+			"scc_lifecycle.SHA.high.open_age.1d_7d":                 0,
+			"scc_lifecycle.SHA.high.open_age.7d_30d":                0,
+			"scc_lifecycle.SHA.high.open_age.gt_30d":                3,
+			"scc_lifecycle.SHA.high.time_to_remediate.mean_seconds": 5400,
+			"scc_lifecycle.SHA.high.time_to_remediate.p50_seconds":  3600,
+			"scc_lifecycle.SHA.high.time_to_remediate.p90_seconds":  7200,
+			"scc_lifecycle.SHA.high.time_to_remediate.p99_seconds":  7200,
+			"scc_lifecycle.SHA.low.created_total":                   0,
+			"scc_lifecycle.SHA.low.resolved_total":                  0,
+			"scc_lifecycle.SHA.low.open_age.lt_1d":                  0,
+			"scc_lifecycle.SHA.low.open_age.1d_7d":                  1,
+			"scc_lifecycle.SHA.low.open_age.7d_30d":                 0,
+			"scc_lifecycle.SHA.low.open_age.gt_30d":                 0,
+		},
+		GenerateSCCFindingsLifecycle("scc_lifecycle.", []api.SCCFindingsLifecycle{
+			{Source: "SHA", Severity: "high", Created: 1, Resolved: 2, OpenLess1Day: 1, OpenOver30Days: 3,
+				MeanTimeToRemediate: time.Minute * 90, P50TimeToRemediate: time.Hour,
+				P90TimeToRemediate: time.Hour * 2, P99TimeToRemediate: time.Hour * 2},
+			{Source: "SHA", Severity: "low", Open1To7Days: 1}}))
+}
+
+func TestGenerateSCCMuteInfo(t *testing.T) {
+	assert.Equal(t, map[string]float64{}, GenerateSCCMuteInfo("", nil),
+		"Run with no metrics should return nil")
+	assert.Equal(t,
+		map[string]float64{
+			"scc_mute.configs_total":                         3,
+			"scc_mute.config.dev-projects.muted":             3,
+			"scc_mute.config.dev-projects.updated_timestamp": 1577836800,
+			"scc_mute.config.unused.muted":                   0,
+			"scc_mute.config.unused.updated_timestamp":       1577836800,
+			"scc_mute.config.manual.updated_timestamp":       1577836800,
+			"scc_mute.config.manual.muted":                   2,
+			"scc_mute.manually_muted":                        1,
+			"scc_mute.category.OPEN_FIREWALL":                4,
+		},
+		GenerateSCCMuteInfo("scc_mute.", &api.SCCMuteInfo{
+			MutedByCategory: map[string]int64{"OPEN_FIREWALL": 4},
+			MutedByConfig:   map[string]int64{"dev-projects": 3, "manual": 2},
+			MutedManually:   1,
+			Configs: []api.SCCMuteConfig{
+				{ID: "dev-projects", UpdateTime: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+				{ID: "unused", UpdateTime: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+				{ID: "manual", UpdateTime: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}}}))
+}
+
+func TestGenerateSCCAssetCounts(t *testing.T) {
+	assert.Equal(t, map[string]float64{}, GenerateSCCAssetCounts("", nil),
+		"Run with no metrics should return nil")
+	assert.Equal(t,
+		map[string]float64{
+			"scc_assets.project.web.google_compute_Instance":                    10,
+			"scc_assets.project.db.google_compute_Instance":                     3,
+			"scc_assets.project.no_project.google_cloud_resourcemanager_Folder": 2,
+			"scc_assets.type.google_compute_Instance":                           13,
+			"scc_assets.type.google_cloud_resourcemanager_Folder":               2,
+		},
+		GenerateSCCAssetCounts("scc_assets.", []api.SCCAssetCount{
+			{ResourceType: "google.cloud.resourcemanager.Folder", Project: api.SCCNoProject, Count: 2},
+			{ResourceType: "google.compute.Instance", Project: "db", Count: 3},
+			{ResourceType: "google.compute.Instance", Project: "web", Count: 10}}))
+}
+
+func TestGenerateSCCNotificationsHealth(t *testing.T) {
+	assert.Equal(t, map[string]float64{}, GenerateSCCNotificationsHealth("", nil),
+		"Run with no metrics should return nil")
+	assert.Equal(t,
+		map[string]float64{
+			"scc_notifications.configs_total":                    3,
+			"scc_notifications.config.failing.healthy":           0,
+			"scc_notifications.config.siem.valid":                1,
+			"scc_notifications.config.siem.healthy":              1,
+			"scc_notifications.config.siem.latest_event_seconds": 60,
+			"scc_notifications.config.bad-topic.valid":           0,
+			"scc_notifications.config.bad-topic.healthy":         0,
+		},
+		GenerateSCCNotificationsHealth("scc_notifications.", []api.SCCNotificationHealth{
+			{ID: "bad-topic", ValidFilter: true},
+			{ID: "failing", ValidTopic: true, CheckError: fmt.Errorf("mock error")},
+			{ID: "siem", ValidTopic: true, ValidFilter: true, FlowChecked: true, LatestEvent: time.Minute, Healthy: true}}))
+}
+
+func TestSCCSourcesDelayAndErrorsLayout(t *testing.T) {
+	metrics := GenerateSSCSourcesDelay("scc_delay.", map[string]time.Duration{"SHA": time.Minute})
+	for k, v := range GenerateSCCSourcesErrors("scc_delay.", map[string]string{"sources/1": "SHA"}, nil) {
+		metrics[k] = v
+	}
+	assert.Equal(t, map[string]float64{"scc_delay.SHA.seconds": 60, "scc_delay.SHA.error": 0}, metrics)
+	// Graphite metric can't be a leaf and a branch at the same time
+	for k := range metrics {
+		for other := range metrics {
+			assert.False(t, strings.HasPrefix(other, k+"."), "Metric %q is a branch of %q", k, other)
+		}
+	}
+}
@@ -15,9 +15,12 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
+	"regexp"
+	"sort"
 	"time"
 
 	"github.com/bookingcom/cloudsec-metrics/api"
@@ -27,25 +30,152 @@ import (
 )
 
 type opts struct {
-	CollectPeriod          time.Duration `long:"collect_period" env:"COLLECT_PERIOD" default:"1m" description:"Time between metrics collection"`
-	PrismAPIUrl            string        `long:"prisma_api_url" env:"PRISMA_API_URL" default:"https://api.eu.prismacloud.io" description:"Prisma API URL"`
-	PrismAPIKey            string        `long:"prisma_api_key" env:"PRISMA_API_KEY" description:"Prisma API key"`
-	PrismAPIPassword       string        `long:"prisma_api_password" env:"PRISMA_API_PASSWORD" description:"Prisma API password"`
-	GraphiteHost           string        `long:"graphite_host" env:"GRAPHITE_HOST" description:"Graphite hostname"`
-	GraphitePort           int           `long:"graphite_port" env:"GRAPHITE_PORT" default:"2003" description:"Graphite port"`
-	GraphitePrefix         string        `long:"graphite_prefix" env:"GRAPHITE_PREFIX" description:"Graphite global prefix"`
-	CompliancePrefix       string        `long:"compliance_prefix" env:"COMPLIANCE_PREFIX" default:"compliance." description:"Graphite compliance metrics prefix"`
-	SCCDelayPrefix         string        `long:"scc_delay_prefix" env:"SCC_DELAY_PREFIX" default:"scc_delay." description:"Graphite SCC sources delay metrics prefix"`
-	SCCHealthMetricName    string        `long:"scc_health_metric_name" env:"SCC_HEALTH_METRIC_NAME" default:"scc_health" description:"Graphite SCC health metric name"`
-	PrismaHealthMetricName string        `long:"prisma_health_metric_name" env:"PRISMA_HEALTH_METRIC_NAME" default:"prisma_health" description:"Graphite Prisma health metric name"`
-	SCCOrgID               string        `long:"scc_org_id" env:"SCC_ORG_ID" description:"Google SCC numeric organisation ID"`
-	SCCSourcesRegex        string        `long:"scc_sources_regex" env:"SCC_SOURCES_REGEX" default:"." description:"Google SCC sources Display Name regexp"`
-	Dbg                    bool          `long:"dbg" env:"DEBUG" description:"debug mode"`
+	CollectPeriod          time.Duration     `long:"collect_period" env:"COLLECT_PERIOD" default:"1m" description:"Time between metrics collection"`
+	PrismAPIUrl            string            `long:"prisma_api_url" env:"PRISMA_API_URL" default:"https://api.eu.prismacloud.io" description:"Prisma API URL"`
+	PrismAPIKey            string            `long:"prisma_api_key" env:"PRISMA_API_KEY" description:"Prisma API key"`
+	PrismAPIPassword       string            `long:"prisma_api_password" env:"PRISMA_API_PASSWORD" description:"Prisma API password"`
+	PrismaMaxRetries       int               `long:"prisma_max_retries" env:"PRISMA_MAX_RETRIES" default:"3" description:"Maximum number of Prisma API call retries"`
+	PrismaRateLimit        float64           `long:"prisma_rate_limit" env:"PRISMA_RATE_LIMIT" default:"2" description:"Prisma API requests per second limit per tenant, 0 to disable"`
+	PrismaTenants          string            `long:"prisma_tenants" env:"PRISMA_TENANTS" description:"Path to JSON file with additional named Prisma tenants"`
+	GraphiteHost           string            `long:"graphite_host" env:"GRAPHITE_HOST" description:"Graphite hostname"`
+	GraphitePort           int               `long:"graphite_port" env:"GRAPHITE_PORT" default:"2003" description:"Graphite port"`
+	GraphitePrefix         string            `long:"graphite_prefix" env:"GRAPHITE_PREFIX" description:"Graphite global prefix"`
+	CompliancePrefix       string            `long:"compliance_prefix" env:"COMPLIANCE_PREFIX" default:"compliance." description:"Graphite compliance metrics prefix"`
+	SCCDelayPrefix         string            `long:"scc_delay_prefix" env:"SCC_DELAY_PREFIX" default:"scc_delay." description:"Graphite SCC sources delay metrics prefix"`
+	SCCHealthMetricName    string            `long:"scc_health_metric_name" env:"SCC_HEALTH_METRIC_NAME" default:"scc_health" description:"Graphite SCC health metric name"`
+	PrismaHealthMetricName string            `long:"prisma_health_metric_name" env:"PRISMA_HEALTH_METRIC_NAME" default:"prisma_health" description:"Graphite Prisma health metric name"`
+	PrismaAPIHealthPrefix  string            `long:"prisma_api_health_prefix" env:"PRISMA_API_HEALTH_PREFIX" default:"prisma_api." description:"Graphite Prisma API latency and errors metrics prefix"`
+	PrismaAlerts           bool              `long:"prisma_alerts" env:"PRISMA_ALERTS" description:"Collect Prisma alerts aging and time to resolve metrics"`
+	PrismaAlertsWindow     time.Duration     `long:"prisma_alerts_window" env:"PRISMA_ALERTS_WINDOW" default:"24h" description:"Window for Prisma alerts time to resolve calculation"`
+	PrismaAlertsPrefix     string            `long:"prisma_alerts_prefix" env:"PRISMA_ALERTS_PREFIX" default:"prisma_alerts." description:"Graphite Prisma alerts metrics prefix"`
+	PrismaPolicies         bool              `long:"prisma_policies" env:"PRISMA_POLICIES" description:"Collect Prisma policies inventory metrics"`
+	PrismaPoliciesPrefix   string            `long:"prisma_policies_prefix" env:"PRISMA_POLICIES_PREFIX" default:"prisma_policies." description:"Graphite Prisma policies metrics prefix"`
+	PrismaAccounts         bool              `long:"prisma_accounts" env:"PRISMA_ACCOUNTS" description:"Collect Prisma cloud accounts ingestion metrics"`
+	PrismaAccountsRefresh  time.Duration     `long:"prisma_accounts_status_refresh" env:"PRISMA_ACCOUNTS_STATUS_REFRESH" default:"15m" description:"Time between Prisma cloud account onboarding status requests, 0 to request it on every collection"`
+	PrismaAccountsPrefix   string            `long:"prisma_accounts_prefix" env:"PRISMA_ACCOUNTS_PREFIX" default:"prisma_accounts." description:"Graphite Prisma cloud accounts metrics prefix"`
+	PrismaInventory        bool              `long:"prisma_inventory" env:"PRISMA_INVENTORY" description:"Collect Prisma asset inventory metrics"`
+	PrismaInventoryPrefix  string            `long:"prisma_inventory_prefix" env:"PRISMA_INVENTORY_PREFIX" default:"prisma_inventory." description:"Graphite Prisma asset inventory metrics prefix"`
+	PrismaRQLQueries       string            `long:"prisma_rql_queries" env:"PRISMA_RQL_QUERIES" description:"Path to JSON file with Prisma RQL queries to collect counts for"`
+	PrismaRQLPrefix        string            `long:"prisma_rql_prefix" env:"PRISMA_RQL_PREFIX" default:"prisma_rql." description:"Graphite Prisma RQL queries metrics prefix"`
+	PrismaAccess           bool              `long:"prisma_access" env:"PRISMA_ACCESS" description:"Collect Prisma access keys and users metrics"`
+	PrismaAccessPrefix     string            `long:"prisma_access_prefix" env:"PRISMA_ACCESS_PREFIX" default:"prisma_access." description:"Graphite Prisma access keys and users metrics prefix"`
+	PrismaLicense          bool              `long:"prisma_license" env:"PRISMA_LICENSE" description:"Collect Prisma license and credits usage metrics"`
+	PrismaLicensePrefix    string            `long:"prisma_license_prefix" env:"PRISMA_LICENSE_PREFIX" default:"prisma_license." description:"Graphite Prisma license metrics prefix"`
+	ComputeConsoleURL      string            `long:"compute_console_url" env:"COMPUTE_CONSOLE_URL" description:"Prisma Cloud Compute console URL"`
+	ComputeUsername        string            `long:"compute_username" env:"COMPUTE_USERNAME" description:"Prisma Cloud Compute username or access key"`
+	ComputePassword        string            `long:"compute_password" env:"COMPUTE_PASSWORD" description:"Prisma Cloud Compute password or access key secret"`
+	ComputePrefix          string            `long:"compute_prefix" env:"COMPUTE_PREFIX" default:"compute." description:"Graphite Prisma Cloud Compute metrics prefix"`
+	SCCOrgID               string            `long:"scc_org_id" env:"SCC_ORG_ID" description:"Google SCC numeric organisation ID"`
+	SCCAPIVersion          string            `long:"scc_api_version" env:"SCC_API_VERSION" default:"v1" choice:"v1" choice:"v2" description:"Google SCC API version"`
+	SCCLocation            string            `long:"scc_location" env:"SCC_LOCATION" default:"global" description:"Google SCC v2 API location of findings and configs"`
+	SCCParents             string            `long:"scc_parents" env:"SCC_PARENTS" description:"Path to JSON file with additional named Google SCC organisations, folders and projects"`
+	SCCSourcesRegex        string            `long:"scc_sources_regex" env:"SCC_SOURCES_REGEX" default:"." description:"Google SCC sources Display Name regexp"`
+	SCCDelayOrderBy        string            `long:"scc_delay_order_by" env:"SCC_DELAY_ORDER_BY" default:"event_time" choice:"event_time" choice:"create_time" description:"Google SCC findings time field used for sources delay calculation"`
+	SCCDelayFilter         string            `long:"scc_delay_filter" env:"SCC_DELAY_FILTER" description:"Google SCC findings filter used for sources delay calculation, for example state=\"ACTIVE\""`
+	SCCDelaySourceFilters  map[string]string `long:"scc_delay_source_filter" env:"SCC_DELAY_SOURCE_FILTERS" env-delim:";" description:"Google SCC findings filter for single source in source_display_name:filter format, overrides scc_delay_filter"`
+	SCCWorkers             int               `long:"scc_workers" env:"SCC_WORKERS" default:"4" description:"Maximum number of Google SCC sources queried at once"`
+	SCCSourcesRefresh      time.Duration     `long:"scc_sources_refresh" env:"SCC_SOURCES_REFRESH" default:"1h" description:"Time between Google SCC sources re-discovery, 0 to disable"`
+	SCCSourcesMetricName   string            `long:"scc_sources_metric_name" env:"SCC_SOURCES_METRIC_NAME" default:"scc_sources" description:"Graphite SCC monitored sources count metric name"`
+	SCCFindings            bool              `long:"scc_findings" env:"SCC_FINDINGS" description:"Collect Google SCC active findings count by category and severity"`
+	SCCFindingsPrefix      string            `long:"scc_findings_prefix" env:"SCC_FINDINGS_PREFIX" default:"scc_findings." description:"Graphite SCC findings metrics prefix"`
+	SCCLifecycle           bool              `long:"scc_lifecycle" env:"SCC_LIFECYCLE" description:"Collect Google SCC findings lifecycle and time to remediate metrics"`
+	SCCLifecycleWindow     time.Duration     `long:"scc_lifecycle_window" env:"SCC_LIFECYCLE_WINDOW" default:"24h" description:"Window for Google SCC created and resolved findings count"`
+	SCCLifecyclePrefix     string            `long:"scc_lifecycle_prefix" env:"SCC_LIFECYCLE_PREFIX" default:"scc_lifecycle." description:"Graphite SCC findings lifecycle metrics prefix"`
+	SCCMute                bool              `long:"scc_mute" env:"SCC_MUTE" description:"Collect Google SCC muted findings and mute configs metrics"`
+	SCCMutePrefix          string            `long:"scc_mute_prefix" env:"SCC_MUTE_PREFIX" default:"scc_mute." description:"Graphite SCC muted findings metrics prefix"`
+	SCCAssets              bool              `long:"scc_assets" env:"SCC_ASSETS" description:"Collect Google SCC assets count by resource type and project, deprecated"`
+	SCCAssetsPrefix        string            `long:"scc_assets_prefix" env:"SCC_ASSETS_PREFIX" default:"scc_assets." description:"Graphite SCC assets metrics prefix"`
+	SCCNotifications       bool              `long:"scc_notifications" env:"SCC_NOTIFICATIONS" description:"Collect Google SCC notification configs health"`
+	SCCNotificationsDelay  time.Duration     `long:"scc_notifications_max_delay" env:"SCC_NOTIFICATIONS_MAX_DELAY" description:"Maximum age of the latest finding matching notification config filter for config to be healthy, 0 to disable the check"`
+	SCCNotificationsPrefix string            `long:"scc_notifications_prefix" env:"SCC_NOTIFICATIONS_PREFIX" default:"scc_notifications." description:"Graphite SCC notification configs health metrics prefix"`
+	SCCOwners              bool              `long:"scc_owners" env:"SCC_OWNERS" description:"Collect Google SCC active high and critical findings count per project and folder"`
+	SCCProjectsRegex       string            `long:"scc_projects_regex" env:"SCC_PROJECTS_REGEX" default:"." description:"Google SCC projects Display Name regexp"`
+	SCCProjectsExclude     string            `long:"scc_projects_exclude_regex" env:"SCC_PROJECTS_EXCLUDE_REGEX" description:"Google SCC projects Display Name regexp to exclude"`
+	SCCOwnersPrefix        string            `long:"scc_owners_prefix" env:"SCC_OWNERS_PREFIX" default:"scc_owners." description:"Graphite SCC findings per project and folder metrics prefix"`
+	Dbg                    bool              `long:"dbg" env:"DEBUG" description:"debug mode"`
 }
 
 type collectors struct {
+	prisma             []*prismaTenant
+	prismaAlertsWindow time.Duration
+	prismaPolicies     bool
+	prismaAccounts     bool
+	prismaInventory    bool
+	prismaAccess       bool
+	prismaLicense      bool
+	rqlQueries         []api.RQLQuery
+	compute            *api.Compute
+	scc                sccCollector
+	sccParents         []*sccParent
+	sccSourcesRefresh  time.Duration
+	sccFindings        bool
+	sccProjectFilter   *api.SCCProjectFilter
+	sccLifecycleWindow time.Duration
+	sccMute            bool
+	sccAssets          bool
+	sccNotifications   bool
+	sccNotifyMaxDelay  time.Duration
+}
+
+// sccCollector is Google Security Command Center data source, implemented by api.SCC
+type sccCollector interface {
+	GetSourcesByName(parent string, nameRegex string) (map[string]string, error)
+	GetLatestEventTime(sources map[string]string) (map[string]time.Duration, map[string]error)
+	GetFindingsCounts(sources map[string]string) ([]api.SCCFindingsCount, map[string]error)
+	GetOwnersFindings(sources map[string]string, filter *api.SCCProjectFilter) (*api.SCCOwnersFindings, map[string]error)
+	GetFindingsLifecycle(sources map[string]string, window time.Duration) ([]api.SCCFindingsLifecycle, map[string]error)
+	GetMuteInfo(parent string, sources map[string]string) (*api.SCCMuteInfo, map[string]error, error)
+	GetAssetCounts(parent string) ([]api.SCCAssetCount, error)
+	GetNotificationsHealth(parent string, maxDelay time.Duration) ([]api.SCCNotificationHealth, error)
+}
+
+// sccParent is Google SCC organisation, folder or project along with its sources, its name
+// is empty for the organisation configured with scc_org_id option and used as metrics prefix for others
+type sccParent struct {
+	name           string
+	parent         string
+	sourcesRegex   string
+	sources        map[string]string
+	sourcesUpdated time.Time
+}
+
+// logError logs given error message along with parent name
+func (p *sccParent) logError(msg string, err error) {
+	if p.name != "" {
+		msg += " for parent " + p.name
+	}
+	log.Printf("[ERROR] %s, %v", msg, err)
+}
+
+// sccParentConfig is single entry of SCC parents file
+type sccParentConfig struct {
+	Name         string `json:"name"`
+	Parent       string `json:"parent"`
+	SourcesRegex string `json:"sources_regex"`
+}
+
+// prismaTenant is Prisma API client along with its name, which is empty for the tenant
+// configured with prisma_api_* options and used as metrics prefix for others
+type prismaTenant struct {
+	name       string
 	prisma     *api.Prisma
-	sccSources map[string]string
+	rqlLastRun map[string]time.Time
+}
+
+// logError logs given error message along with tenant name
+func (t *prismaTenant) logError(msg string, err error) {
+	if t.name != "" {
+		msg += " for tenant " + t.name
+	}
+	log.Printf("[ERROR] %s, %v", msg, err)
+}
+
+// prismaTenantConfig is single entry of Prisma tenants file
+type prismaTenantConfig struct {
+	Name        string `json:"name"`
+	APIUrl      string `json:"api_url"`
+	APIKey      string `json:"api_key"`
+	APIPassword string `json:"api_password"`
 }
 
 type senders struct {
@@ -53,12 +183,40 @@ type senders struct {
 }
 
 type metrics struct {
-	complianceInfo        []api.ComplianceInfo
-	googleSourcesDelay    map[string]time.Duration
-	prismaHealthStatus    int
+	prisma                map[string]*prismaMetrics
+	defendersInfo         *api.DefendersInfo
+	vulnerabilityInfo     *api.VulnerabilityInfo
+	scc                   map[string]*sccMetrics
 	googleSCCHealthStatus int
 }
 
+// sccMetrics stores metrics of single SCC parent
+type sccMetrics struct {
+	sources        map[string]string
+	sourcesDelay   map[string]time.Duration
+	sourcesErrors  map[string]error
+	findingsCounts []api.SCCFindingsCount
+	ownersFindings *api.SCCOwnersFindings
+	lifecycle      []api.SCCFindingsLifecycle
+	muteInfo       *api.SCCMuteInfo
+	assetCounts    []api.SCCAssetCount
+	notifications  []api.SCCNotificationHealth
+}
+
+// prismaMetrics stores metrics of single Prisma tenant
+type prismaMetrics struct {
+	complianceInfo    []api.ComplianceInfo
+	alertAgingInfo    []api.AlertAgingInfo
+	policyInventory   *api.PolicyInventory
+	cloudAccountsInfo []api.CloudAccountInfo
+	assetInventory    *api.AssetInventory
+	rqlResults        map[string]*api.RQLResult
+	accessHygieneInfo *api.AccessHygieneInfo
+	licenseInfo       *api.LicenseInfo
+	apiHealth         *api.APIHealth
+	callStats         *api.CallStats
+}
+
 func main() {
 	var opts = opts{}
 	if _, err := flags.Parse(&opts); err != nil {
@@ -89,19 +247,168 @@ func prepareCollectors(opts opts) (*collectors, error) {
 	var collectors = &collectors{}
 	if opts.PrismAPIKey != "" && opts.PrismAPIPassword != "" {
 		log.Printf("[INFO] Initialising Prisma data collection with API key %s", opts.PrismAPIKey)
-		collectors.prisma = api.NewPrisma(opts.PrismAPIKey, opts.PrismAPIPassword, opts.PrismAPIUrl)
+		collectors.prisma = append(collectors.prisma, &prismaTenant{
+			prisma:     api.NewPrisma(opts.PrismAPIKey, opts.PrismAPIPassword, opts.PrismAPIUrl),
+			rqlLastRun: map[string]time.Time{}})
+	}
+	if opts.PrismaTenants != "" {
+		tenants, err := loadPrismaTenants(opts.PrismaTenants, opts.PrismAPIUrl)
+		if err != nil {
+			return nil, err
+		}
+		collectors.prisma = append(collectors.prisma, tenants...)
+	}
+	for _, tenant := range collectors.prisma {
+		tenant.prisma.SetRetryPolicy(opts.PrismaMaxRetries, opts.PrismaRateLimit)
+		tenant.prisma.SetAccountStatusRefresh(opts.PrismaAccountsRefresh)
+	}
+	if len(collectors.prisma) != 0 {
+		if opts.PrismaAlerts {
+			collectors.prismaAlertsWindow = opts.PrismaAlertsWindow
+		}
+		collectors.prismaPolicies = opts.PrismaPolicies
+		collectors.prismaAccounts = opts.PrismaAccounts
+		collectors.prismaInventory = opts.PrismaInventory
+		collectors.prismaAccess = opts.PrismaAccess
+		collectors.prismaLicense = opts.PrismaLicense
+		if opts.PrismaRQLQueries != "" {
+			data, err := os.ReadFile(opts.PrismaRQLQueries)
+			if err != nil {
+				return nil, fmt.Errorf("can't read Prisma RQL queries file: %w", err)
+			}
+			if collectors.rqlQueries, err = api.ParseRQLQueries(data); err != nil {
+				return nil, fmt.Errorf("can't parse Prisma RQL queries file: %w", err)
+			}
+			log.Printf("[INFO] Loaded %d Prisma RQL queries", len(collectors.rqlQueries))
+		}
+	}
+	if opts.ComputeConsoleURL != "" && opts.ComputeUsername != "" && opts.ComputePassword != "" {
+		log.Printf("[INFO] Initialising Prisma Cloud Compute data collection from %s", opts.ComputeConsoleURL)
+		collectors.compute = api.NewCompute(opts.ComputeUsername, opts.ComputePassword, opts.ComputeConsoleURL)
 	}
 	if opts.SCCOrgID != "" {
-		var err error
 		log.Printf("[INFO] Initialising Google Security Command Center data collection for Organisation ID %s", opts.SCCOrgID)
-		collectors.sccSources, err = api.GetSCCSourcesByName(opts.SCCOrgID, opts.SCCSourcesRegex)
+		collectors.sccParents = append(collectors.sccParents, &sccParent{parent: opts.SCCOrgID, sourcesRegex: opts.SCCSourcesRegex})
+	}
+	if opts.SCCParents != "" {
+		parents, err := loadSCCParents(opts.SCCParents, opts.SCCSourcesRegex)
+		if err != nil {
+			return nil, err
+		}
+		collectors.sccParents = append(collectors.sccParents, parents...)
+	}
+	if len(collectors.sccParents) != 0 {
+		var err error
+		if opts.SCCOwners {
+			if collectors.sccProjectFilter, err = api.NewSCCProjectFilter(opts.SCCProjectsRegex, opts.SCCProjectsExclude); err != nil {
+				return nil, fmt.Errorf("can't parse SCC projects filter: %w", err)
+			}
+		}
+		var scc *api.SCC
+		if opts.SCCAPIVersion == api.SCCAPIv2 {
+			if opts.SCCAssets || opts.SCCLifecycle {
+				return nil, fmt.Errorf("SCC assets and findings lifecycle collection are not supported with SCC %s API", api.SCCAPIv2)
+			}
+			scc, err = api.NewSCCV2(opts.SCCLocation)
+		} else {
+			scc, err = api.NewSCC()
+		}
 		if err != nil {
-			return nil, fmt.Errorf("can't get SCC sources information: %w", err)
+			return nil, fmt.Errorf("can't create SCC client: %w", err)
 		}
+		scc.SetWorkers(opts.SCCWorkers)
+		if err = scc.SetDelayQuery(api.SCCDelayQuery{OrderBy: opts.SCCDelayOrderBy, Filter: opts.SCCDelayFilter,
+			SourceFilters: opts.SCCDelaySourceFilters}); err != nil {
+			_ = scc.Close()
+			return nil, fmt.Errorf("can't set SCC delay query: %w", err)
+		}
+		collectors.scc = scc
+		for _, p := range collectors.sccParents {
+			if p.sources, err = scc.GetSourcesByName(p.parent, p.sourcesRegex); err != nil {
+				_ = scc.Close()
+				return nil, fmt.Errorf("can't get SCC sources information of %s: %w", p.parent, err)
+			}
+			p.sourcesUpdated = time.Now()
+		}
+		collectors.sccSourcesRefresh = opts.SCCSourcesRefresh
+		collectors.sccFindings = opts.SCCFindings
+		if opts.SCCLifecycle {
+			collectors.sccLifecycleWindow = opts.SCCLifecycleWindow
+		}
+		collectors.sccMute = opts.SCCMute
+		collectors.sccAssets = opts.SCCAssets
+		collectors.sccNotifications = opts.SCCNotifications
+		collectors.sccNotifyMaxDelay = opts.SCCNotificationsDelay
 	}
 	return collectors, nil
 }
 
+// loadPrismaTenants returns Prisma tenants described in given JSON file,
+// defaultURL is used for tenants without api_url set
+func loadPrismaTenants(path, defaultURL string) ([]*prismaTenant, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("can't read Prisma tenants file: %w", err)
+	}
+	var configs []prismaTenantConfig
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return nil, fmt.Errorf("can't parse Prisma tenants file: %w", err)
+	}
+	// tenant name is used as metrics prefix as is
+	nameRegex := regexp.MustCompile(`^[\w-]+$`)
+	tenants := make([]*prismaTenant, 0, len(configs))
+	names := map[string]bool{}
+	for _, c := range configs {
+		if !nameRegex.MatchString(c.Name) || names[c.Name] {
+			return nil, fmt.Errorf("bad Prisma tenant name %q, it must be unique and contain only letters, digits, _ and -", c.Name)
+		}
+		names[c.Name] = true
+		if c.APIKey == "" || c.APIPassword == "" {
+			return nil, fmt.Errorf("API key and password are required for Prisma tenant %q", c.Name)
+		}
+		if c.APIUrl == "" {
+			c.APIUrl = defaultURL
+		}
+		log.Printf("[INFO] Initialising Prisma data collection for tenant %s with API key %s", c.Name, c.APIKey)
+		tenants = append(tenants, &prismaTenant{name: c.Name, prisma: api.NewPrisma(c.APIKey, c.APIPassword, c.APIUrl),
+			rqlLastRun: map[string]time.Time{}})
+	}
+	return tenants, nil
+}
+
+// loadSCCParents returns SCC parents described in given JSON file,
+// defaultRegex is used for parents without sources_regex set
+func loadSCCParents(path, defaultRegex string) ([]*sccParent, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("can't read SCC parents file: %w", err)
+	}
+	var configs []sccParentConfig
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return nil, fmt.Errorf("can't parse SCC parents file: %w", err)
+	}
+	// parent name is used as metrics prefix as is
+	nameRegex := regexp.MustCompile(`^[\w-]+$`)
+	parentRegex := regexp.MustCompile(`^(organizations/\d+|folders/\d+|projects/[\w-]+)$`)
+	parents := make([]*sccParent, 0, len(configs))
+	names := map[string]bool{}
+	for _, c := range configs {
+		if !nameRegex.MatchString(c.Name) || names[c.Name] {
+			return nil, fmt.Errorf("bad SCC parent name %q, it must be unique and contain only letters, digits, _ and -", c.Name)
+		}
+		names[c.Name] = true
+		if !parentRegex.MatchString(c.Parent) {
+			return nil, fmt.Errorf("bad SCC parent %q of %q, it must be organizations/<id>, folders/<id> or projects/<id>", c.Parent, c.Name)
+		}
+		if c.SourcesRegex == "" {
+			c.SourcesRegex = defaultRegex
+		}
+		log.Printf("[INFO] Initialising Google Security Command Center data collection for %s as %s", c.Parent, c.Name)
+		parents = append(parents, &sccParent{name: c.Name, parent: c.Parent, sourcesRegex: c.SourcesRegex})
+	}
+	return parents, nil
+}
+
 // create and return a pointer to senders
 func prepareSenders(opts opts) *senders {
 	var senders = &senders{}
@@ -114,19 +421,201 @@ func prepareSenders(opts opts) *senders {
 // collectMetrics collects metrics into referenced metrics object using provided collectors
 func collectMetrics(metrics *metrics, collectors *collectors, googleHealthDashboard string) {
 	var err error
-	if collectors.prisma != nil {
-		if metrics.complianceInfo, err = collectors.prisma.GatherComplianceInfo(); err != nil {
-			log.Printf("[ERROR] Can't request compliance information, %v", err)
+	for _, tenant := range collectors.prisma {
+		if metrics.prisma == nil {
+			metrics.prisma = map[string]*prismaMetrics{}
+		}
+		if metrics.prisma[tenant.name] == nil {
+			metrics.prisma[tenant.name] = &prismaMetrics{}
+		}
+		collectPrismaMetrics(metrics.prisma[tenant.name], tenant, collectors)
+	}
+	if collectors.compute != nil {
+		if metrics.defendersInfo, err = collectors.compute.GatherDefendersInfo(); err != nil {
+			log.Printf("[ERROR] Can't request Compute defenders information, %v", err)
+		}
+		if metrics.vulnerabilityInfo, err = collectors.compute.GatherVulnerabilityInfo(); err != nil {
+			log.Printf("[ERROR] Can't request Compute vulnerabilities information, %v", err)
 		}
-		metrics.prismaHealthStatus = collectors.prisma.GetAPIHealthStatus()
 	}
 	if googleHealthDashboard != "" {
 		metrics.googleSCCHealthStatus = api.GetSCCHealthStatus(googleHealthDashboard)
 	}
-	if collectors.sccSources != nil {
-		if metrics.googleSourcesDelay, err = api.GetSCCLatestEventTime(collectors.sccSources); err != nil {
-			log.Printf("[ERROR] Can't get SCC sources last update information, %v", err)
+	for _, p := range collectors.sccParents {
+		if metrics.scc == nil {
+			metrics.scc = map[string]*sccMetrics{}
+		}
+		if metrics.scc[p.name] == nil {
+			metrics.scc[p.name] = &sccMetrics{}
+		}
+		collectSCCMetrics(metrics.scc[p.name], p, collectors)
+	}
+}
+
+// collectSCCMetrics collects metrics of single SCC parent into referenced metrics object
+func collectSCCMetrics(metrics *sccMetrics, p *sccParent, collectors *collectors) {
+	var err error
+	refreshSCCSources(p, collectors.scc, collectors.sccSourcesRefresh)
+	metrics.sources = p.sources
+	metrics.sourcesDelay, metrics.sourcesErrors = collectors.scc.GetLatestEventTime(p.sources)
+	for name, err := range metrics.sourcesErrors {
+		p.logError(fmt.Sprintf("Can't get SCC source %q last update information", name), err)
+	}
+	if collectors.sccFindings {
+		var errs map[string]error
+		metrics.findingsCounts, errs = collectors.scc.GetFindingsCounts(p.sources)
+		for name, err := range errs {
+			p.logError(fmt.Sprintf("Can't get SCC source %q findings counts", name), err)
+		}
+	}
+	if collectors.sccProjectFilter != nil {
+		var errs map[string]error
+		metrics.ownersFindings, errs = collectors.scc.GetOwnersFindings(p.sources, collectors.sccProjectFilter)
+		for name, err := range errs {
+			p.logError(fmt.Sprintf("Can't get SCC source %q findings counts per project", name), err)
+		}
+	}
+	if collectors.sccLifecycleWindow != 0 {
+		var errs map[string]error
+		metrics.lifecycle, errs = collectors.scc.GetFindingsLifecycle(p.sources, collectors.sccLifecycleWindow)
+		for name, err := range errs {
+			p.logError(fmt.Sprintf("Can't get SCC source %q findings lifecycle information", name), err)
+		}
+	}
+	if collectors.sccMute {
+		var errs map[string]error
+		if metrics.muteInfo, errs, err = collectors.scc.GetMuteInfo(p.parent, p.sources); err != nil {
+			p.logError("Can't get SCC muted findings information", err)
+		}
+		for name, err := range errs {
+			p.logError(fmt.Sprintf("Can't get SCC source %q muted findings information", name), err)
+		}
+	}
+	if collectors.sccAssets {
+		if metrics.assetCounts, err = collectors.scc.GetAssetCounts(p.parent); err != nil {
+			p.logError("Can't get SCC assets counts", err)
+		}
+	}
+	if collectors.sccNotifications {
+		if metrics.notifications, err = collectors.scc.GetNotificationsHealth(p.parent, collectors.sccNotifyMaxDelay); err != nil {
+			p.logError("Can't get SCC notification configs health", err)
+		}
+		for _, h := range metrics.notifications {
+			if h.CheckError != nil {
+				p.logError(fmt.Sprintf("Can't check SCC notification config %q filter", h.ID), h.CheckError)
+			}
+		}
+	}
+}
+
+// refreshSCCSources re-discovers SCC sources of given parent when refresh period passed since previous discovery,
+// keeping known sources in case of error
+func refreshSCCSources(p *sccParent, scc sccCollector, refresh time.Duration) {
+	if refresh == 0 || time.Since(p.sourcesUpdated) < refresh {
+		return
+	}
+	p.sourcesUpdated = time.Now()
+	sources, err := scc.GetSourcesByName(p.parent, p.sourcesRegex)
+	if err != nil {
+		p.logError(fmt.Sprintf("Can't refresh SCC sources, keeping %d known ones", len(p.sources)), err)
+		return
+	}
+	added, removed := diffSCCSources(p.sources, sources)
+	for _, id := range added {
+		log.Printf("[INFO] SCC source %q (%s) is added to monitoring", sources[id], id)
+	}
+	for _, id := range removed {
+		log.Printf("[INFO] SCC source %q (%s) is removed from monitoring", p.sources[id], id)
+	}
+	p.sources = sources
+}
+
+// diffSCCSources returns sorted IDs of sources present only in discovered and only in known sources maps
+func diffSCCSources(known, discovered map[string]string) (added, removed []string) {
+	for id := range discovered {
+		if _, ok := known[id]; !ok {
+			added = append(added, id)
+		}
+	}
+	for id := range known {
+		if _, ok := discovered[id]; !ok {
+			removed = append(removed, id)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+// collectPrismaMetrics collects metrics of single Prisma tenant into referenced metrics object
+func collectPrismaMetrics(metrics *prismaMetrics, tenant *prismaTenant, collectors *collectors) {
+	var err error
+	if metrics.complianceInfo, err = tenant.prisma.GatherComplianceInfo(); err != nil {
+		tenant.logError("Can't request compliance information", err)
+	}
+	health := tenant.prisma.GetAPIHealth()
+	metrics.apiHealth = &health
+	if collectors.prismaAlertsWindow != 0 {
+		if metrics.alertAgingInfo, err = tenant.prisma.GatherAlertAgingInfo(collectors.prismaAlertsWindow); err != nil {
+			tenant.logError("Can't request alerts aging information", err)
+		}
+	}
+	if collectors.prismaPolicies {
+		if metrics.policyInventory, err = tenant.prisma.GatherPolicyInventory(); err != nil {
+			tenant.logError("Can't request policies information", err)
+		}
+	}
+	if collectors.prismaAccounts {
+		if metrics.cloudAccountsInfo, err = tenant.prisma.GatherCloudAccountsInfo(); err != nil {
+			tenant.logError("Can't request cloud accounts information", err)
+		}
+		for _, account := range metrics.cloudAccountsInfo {
+			if account.StatusError != nil {
+				tenant.logError(fmt.Sprintf("Can't request cloud account %q status", account.Name), account.StatusError)
+			}
+		}
+	}
+	if collectors.prismaInventory {
+		if metrics.assetInventory, err = tenant.prisma.GatherAssetInventory(); err != nil {
+			tenant.logError("Can't request asset inventory", err)
+		}
+	}
+	if collectors.prismaAccess {
+		if metrics.accessHygieneInfo, err = tenant.prisma.GatherAccessHygieneInfo(); err != nil {
+			tenant.logError("Can't request access keys and users information", err)
+		}
+	}
+	if collectors.prismaLicense {
+		if metrics.licenseInfo, err = tenant.prisma.GatherLicenseInfo(); err != nil {
+			tenant.logError("Can't request license information", err)
+		}
+	}
+	collectRQLResults(metrics, tenant, collectors.rqlQueries)
+	stats := tenant.prisma.GetCallStats()
+	metrics.callStats = &stats
+}
+
+// collectRQLResults runs RQL queries which period passed since their previous run,
+// results of queries failed to run are discarded
+func collectRQLResults(metrics *prismaMetrics, tenant *prismaTenant, queries []api.RQLQuery) {
+	for _, q := range queries {
+		if time.Since(tenant.rqlLastRun[q.Name]) < q.Period {
+			continue
+		}
+		tenant.rqlLastRun[q.Name] = time.Now()
+		if metrics.rqlResults == nil {
+			metrics.rqlResults = map[string]*api.RQLResult{}
+		}
+		result, err := tenant.prisma.RunRQLQuery(q)
+		if err != nil {
+			tenant.logError("Can't run RQL query", err)
+			delete(metrics.rqlResults, q.Name)
+			continue
+		}
+		if result.Truncated {
+			log.Printf("[INFO] Prisma RQL query %q result is truncated, its group counts are incomplete", q.Name)
 		}
+		metrics.rqlResults[q.Name] = result
 	}
 }
 
@@ -134,18 +623,110 @@ func collectMetrics(metrics *metrics, collectors *collectors, googleHealthDashbo
 func sendMetrics(metrics *metrics, senders *senders, opts opts) {
 	if senders.graphite != nil {
 		graphiteMetrics := map[string]float64{}
-		if metrics.complianceInfo != nil {
-			for k, v := range graphite.GenerateComplianceInfo(opts.CompliancePrefix, metrics.complianceInfo) {
+		for tenant, m := range metrics.prisma {
+			for k, v := range generatePrismaGraphiteMetrics(m, tenantPrefix(tenant), opts) {
 				graphiteMetrics[k] = v
 			}
-			graphiteMetrics[opts.PrismaHealthMetricName] = float64(metrics.prismaHealthStatus)
 		}
-		for k, v := range graphite.GenerateSSCSourcesDelay(opts.SCCDelayPrefix, metrics.googleSourcesDelay) {
+		for k, v := range graphite.GenerateComputeDefenders(opts.ComputePrefix, metrics.defendersInfo) {
 			graphiteMetrics[k] = v
 		}
+		for k, v := range graphite.GenerateComputeVulnerabilities(opts.ComputePrefix, metrics.vulnerabilityInfo) {
+			graphiteMetrics[k] = v
+		}
+		for name, m := range metrics.scc {
+			for k, v := range generateSCCGraphiteMetrics(m, tenantPrefix(name), opts) {
+				graphiteMetrics[k] = v
+			}
+		}
 		graphiteMetrics[opts.SCCHealthMetricName] = float64(metrics.googleSCCHealthStatus)
 		if err := senders.graphite.SendData(graphiteMetrics); err != nil {
 			log.Printf("[ERROR] Can't send metrics to Graphite, %v", err)
 		}
 	}
 }
+
+// generatePrismaGraphiteMetrics returns Graphite metrics of single Prisma tenant with given prefix applied
+func generatePrismaGraphiteMetrics(metrics *prismaMetrics, prefix string, opts opts) map[string]float64 {
+	graphiteMetrics := map[string]float64{}
+	if metrics.complianceInfo != nil {
+		for k, v := range graphite.GenerateComplianceInfo(prefix+opts.CompliancePrefix, metrics.complianceInfo) {
+			graphiteMetrics[k] = v
+		}
+		if metrics.apiHealth != nil {
+			graphiteMetrics[prefix+opts.PrismaHealthMetricName] = 1
+			if metrics.apiHealth.SLABreach() {
+				graphiteMetrics[prefix+opts.PrismaHealthMetricName] = 0
+			}
+		}
+	}
+	for k, v := range graphite.GeneratePrismaAPIHealth(prefix+opts.PrismaAPIHealthPrefix, metrics.apiHealth) {
+		graphiteMetrics[k] = v
+	}
+	for k, v := range graphite.GeneratePrismaCallStats(prefix+opts.PrismaAPIHealthPrefix, metrics.callStats) {
+		graphiteMetrics[k] = v
+	}
+	for k, v := range graphite.GeneratePrismaAlertAging(prefix+opts.PrismaAlertsPrefix, metrics.alertAgingInfo) {
+		graphiteMetrics[k] = v
+	}
+	for k, v := range graphite.GeneratePrismaPolicyInventory(prefix+opts.PrismaPoliciesPrefix, metrics.policyInventory) {
+		graphiteMetrics[k] = v
+	}
+	for k, v := range graphite.GeneratePrismaCloudAccounts(prefix+opts.PrismaAccountsPrefix, metrics.cloudAccountsInfo) {
+		graphiteMetrics[k] = v
+	}
+	for k, v := range graphite.GeneratePrismaAssetInventory(prefix+opts.PrismaInventoryPrefix, metrics.assetInventory) {
+		graphiteMetrics[k] = v
+	}
+	for k, v := range graphite.GeneratePrismaRQLResults(prefix+opts.PrismaRQLPrefix, metrics.rqlResults) {
+		graphiteMetrics[k] = v
+	}
+	for k, v := range graphite.GeneratePrismaAccessHygiene(prefix+opts.PrismaAccessPrefix, metrics.accessHygieneInfo) {
+		graphiteMetrics[k] = v
+	}
+	for k, v := range graphite.GeneratePrismaLicense(prefix+opts.PrismaLicensePrefix, metrics.licenseInfo) {
+		graphiteMetrics[k] = v
+	}
+	return graphiteMetrics
+}
+
+// generateSCCGraphiteMetrics returns Graphite metrics of single SCC parent with given prefix applied
+func generateSCCGraphiteMetrics(metrics *sccMetrics, prefix string, opts opts) map[string]float64 {
+	graphiteMetrics := map[string]float64{}
+	if metrics.sources != nil {
+		graphiteMetrics[prefix+opts.SCCSourcesMetricName] = float64(len(metrics.sources))
+	}
+	for k, v := range graphite.GenerateSSCSourcesDelay(prefix+opts.SCCDelayPrefix, metrics.sourcesDelay) {
+		graphiteMetrics[k] = v
+	}
+	for k, v := range graphite.GenerateSCCSourcesErrors(prefix+opts.SCCDelayPrefix, metrics.sources, metrics.sourcesErrors) {
+		graphiteMetrics[k] = v
+	}
+	for k, v := range graphite.GenerateSCCFindingsCounts(prefix+opts.SCCFindingsPrefix, metrics.findingsCounts) {
+		graphiteMetrics[k] = v
+	}
+	for k, v := range graphite.GenerateSCCFindingsLifecycle(prefix+opts.SCCLifecyclePrefix, metrics.lifecycle) {
+		graphiteMetrics[k] = v
+	}
+	for k, v := range graphite.GenerateSCCMuteInfo(prefix+opts.SCCMutePrefix, metrics.muteInfo) {
+		graphiteMetrics[k] = v
+	}
+	for k, v := range graphite.GenerateSCCAssetCounts(prefix+opts.SCCAssetsPrefix, metrics.assetCounts) {
+		graphiteMetrics[k] = v
+	}
+	for k, v := range graphite.GenerateSCCNotificationsHealth(prefix+opts.SCCNotificationsPrefix, metrics.notifications) {
+		graphiteMetrics[k] = v
+	}
+	for k, v := range graphite.GenerateSCCOwnersFindings(prefix+opts.SCCOwnersPrefix, metrics.ownersFindings) {
+		graphiteMetrics[k] = v
+	}
+	return graphiteMetrics
+}
+
+// tenantPrefix returns metrics prefix for Prisma tenant or SCC parent with given name
+func tenantPrefix(name string) string {
+	if name == "" {
+		return ""
+	}
+	return name + "."
+}
@@ -15,12 +15,17 @@
 package main
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/jtaczanowski/go-graphite-client"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/bookingcom/cloudsec-metrics/api"
+	"github.com/bookingcom/cloudsec-metrics/api/prismatest"
 )
 
 func TestPrepareCollectors(t *testing.T) {
@@ -30,9 +35,19 @@ func TestPrepareCollectors(t *testing.T) {
 		collectors *collectors
 	}{
 		{collectors: &collectors{}},
-		{collectors: &collectors{prisma: api.NewPrisma("bad", "bad_pass", "bad_host")},
+		{collectors: &collectors{prisma: []*prismaTenant{badTenant("")}},
 			opts: opts{PrismAPIKey: "bad", PrismAPIPassword: "bad_pass", PrismAPIUrl: "bad_host"}},
+		{collectors: &collectors{prisma: []*prismaTenant{badTenant("")}, prismaAlertsWindow: time.Hour},
+			opts: opts{PrismAPIKey: "bad", PrismAPIPassword: "bad_pass", PrismAPIUrl: "bad_host",
+				PrismaAlerts: true, PrismaAlertsWindow: time.Hour}},
+		{collectors: &collectors{compute: api.NewCompute("user", "pass", "bad_host")},
+			opts: opts{ComputeUsername: "user", ComputePassword: "pass", ComputeConsoleURL: "bad_host"}},
 		{opts: opts{SCCOrgID: "bad"}, err: true},
+		{opts: opts{SCCOrgID: "bad", SCCOwners: true, SCCProjectsRegex: "bad_regex("}, err: true},
+		{opts: opts{PrismAPIKey: "bad", PrismAPIPassword: "bad_pass", PrismaRQLQueries: "nonexistent.json"}, err: true},
+		{opts: opts{PrismaTenants: "nonexistent.json"}, err: true},
+		{opts: opts{SCCParents: "nonexistent.json"}, err: true},
+		{opts: opts{SCCOrgID: "1", SCCAPIVersion: "v2", SCCAssets: true}, err: true},
 	}
 	for i, x := range testDataset {
 		c, err := prepareCollectors(x.opts)
@@ -45,6 +60,39 @@ func TestPrepareCollectors(t *testing.T) {
 	}
 }
 
+func TestLoadPrismaTenants(t *testing.T) {
+	var testDataset = []struct {
+		data    string
+		error   string
+		tenants []*prismaTenant
+	}{
+		{data: "not_json",
+			error: "can't parse Prisma tenants file: invalid character 'o' in literal null (expecting 'u')"},
+		{data: `[{"name":"bad.name","api_key":"bad","api_password":"bad_pass"}]`,
+			error: `bad Prisma tenant name "bad.name", it must be unique and contain only letters, digits, _ and -`},
+		{data: `[{"name":"eu","api_key":"bad","api_password":"bad_pass"},{"name":"eu","api_key":"bad","api_password":"bad_pass"}]`,
+			error: `bad Prisma tenant name "eu", it must be unique and contain only letters, digits, _ and -`},
+		{data: `[{"name":"eu","api_key":"bad"}]`,
+			error: `API key and password are required for Prisma tenant "eu"`},
+		{data: `[{"name":"eu","api_key":"bad","api_password":"bad_pass"},
+{"name":"us","api_key":"bad","api_password":"bad_pass","api_url":"bad_host"}]`,
+			tenants: []*prismaTenant{
+				{name: "eu", prisma: api.NewPrisma("bad", "bad_pass", "default_host"), rqlLastRun: map[string]time.Time{}},
+				{name: "us", prisma: api.NewPrisma("bad", "bad_pass", "bad_host"), rqlLastRun: map[string]time.Time{}}}},
+	}
+	for i, x := range testDataset {
+		path := filepath.Join(t.TempDir(), "tenants.json")
+		assert.NoError(t, os.WriteFile(path, []byte(x.data), 0o600))
+		tenants, err := loadPrismaTenants(path, "default_host")
+		if x.error != "" {
+			assert.EqualError(t, err, x.error, "Test case %d error check failed", i)
+		} else {
+			assert.NoError(t, err, "Test case %d error check failed", i)
+		}
+		assert.Equal(t, x.tenants, tenants, "Test case %d tenants check failed", i)
+	}
+}
+
 func TestPrepareSenders(t *testing.T) {
 	s := prepareSenders(opts{})
 	assert.Equal(t, &senders{}, s, "No senders initialised without options provided")
@@ -56,8 +104,258 @@ func TestCollectMetrics(t *testing.T) {
 	assert.Equal(t, metrics{}, m, "No data collected without collectors provided")
 }
 
+func TestCollectMetrics_Prisma(t *testing.T) {
+	s := prismatest.NewServer("key", "secret")
+	defer s.Close()
+	o := opts{PrismAPIKey: "key", PrismAPIPassword: "secret", PrismAPIUrl: s.URL,
+		CompliancePrefix: "compliance.", PrismaHealthMetricName: "prisma_health", PrismaAPIHealthPrefix: "prisma_api."}
+	c, err := prepareCollectors(o)
+	assert.NoError(t, err)
+
+	var testDataset = []struct {
+		fault   *prismatest.Fault
+		metrics map[string]float64
+	}{
+		{metrics: map[string]float64{"compliance.CIS.policies_total": 10, "compliance.CIS.assets_total": 100,
+			"prisma_health": 1, "prisma_api.status_class": 2, "prisma_api.errors.server_error": 0}},
+		{fault: &prismatest.Fault{StatusCode: 503},
+			metrics: map[string]float64{"prisma_health": 0, "prisma_api.status_class": 5, "prisma_api.errors.server_error": 1}},
+		{fault: &prismatest.Fault{StatusCode: 429},
+			metrics: map[string]float64{"prisma_health": 1, "prisma_api.status_class": 4, "prisma_api.errors.rate_limit": 1}},
+	}
+	for i, x := range testDataset {
+		s.ClearFaults()
+		if x.fault != nil {
+			s.InjectFault("/check", *x.fault)
+		}
+		m := metrics{}
+		collectMetrics(&m, c, "")
+		graphiteMetrics := generatePrismaGraphiteMetrics(m.prisma[""], "", o)
+		for k, v := range x.metrics {
+			assert.Equal(t, v, graphiteMetrics[k], "Test case %d metric %s check failed", i, k)
+		}
+	}
+	assert.Equal(t, 1, s.Requests("/login"), "Token is reused between collection runs")
+}
+
 func TestSendMetrics(t *testing.T) {
-	m := metrics{complianceInfo: []api.ComplianceInfo{}}
+	m := metrics{prisma: map[string]*prismaMetrics{"": {complianceInfo: []api.ComplianceInfo{}}}}
 	sendMetrics(&m, &senders{graphite: &graphite.Client{}}, opts{})
-	assert.Equal(t, metrics{complianceInfo: []api.ComplianceInfo{}}, m, "Metrics unchanged after send function call")
+	assert.Equal(t, metrics{prisma: map[string]*prismaMetrics{"": {complianceInfo: []api.ComplianceInfo{}}}}, m,
+		"Metrics unchanged after send function call")
+}
+
+func TestGeneratePrismaGraphiteMetrics(t *testing.T) {
+	m := &prismaMetrics{complianceInfo: []api.ComplianceInfo{{Name: "std", PoliciesCount: 1}}}
+	o := opts{CompliancePrefix: "compliance.", PrismaHealthMetricName: "prisma_health"}
+	assert.Equal(t, map[string]float64{
+		"compliance.std.policies_total": 1,
+		"compliance.std.assets_passed":  0,
+		"compliance.std.assets_failed":  0,
+		"compliance.std.assets_total":   0,
+	}, generatePrismaGraphiteMetrics(m, tenantPrefix(""), o), "Default tenant metrics are not prefixed")
+	assert.Equal(t, map[string]float64{
+		"eu.compliance.std.policies_total": 1,
+		"eu.compliance.std.assets_passed":  0,
+		"eu.compliance.std.assets_failed":  0,
+		"eu.compliance.std.assets_total":   0,
+	}, generatePrismaGraphiteMetrics(m, tenantPrefix("eu"), o), "Named tenant metrics are prefixed with its name")
+
+	o.PrismaAPIHealthPrefix = "prisma_api."
+	for _, x := range []struct {
+		health api.APIHealth
+		status float64
+	}{
+		{health: api.APIHealth{StatusCode: 200}, status: 1},
+		{health: api.APIHealth{StatusCode: 401, ErrorClass: api.HealthErrorAuth}, status: 1},
+		{health: api.APIHealth{StatusCode: 503, ErrorClass: api.HealthErrorServer}, status: 0},
+	} {
+		health := x.health
+		m.apiHealth = &health
+		graphiteMetrics := generatePrismaGraphiteMetrics(m, "", o)
+		assert.Equal(t, x.status, graphiteMetrics["prisma_health"], "Only Prisma side failures are reported as unhealthy")
+		assert.Contains(t, graphiteMetrics, "prisma_api.latency_seconds")
+	}
+}
+
+func TestCollectRQLResults(t *testing.T) {
+	m := prismaMetrics{rqlResults: map[string]*api.RQLResult{"q": {Name: "q", Count: 1}, "fresh": {Name: "fresh", Count: 2}}}
+	tenant := badTenant("")
+	tenant.rqlLastRun["fresh"] = time.Now()
+	queries := []api.RQLQuery{
+		{Name: "q", Type: api.RQLConfig, Query: "config from cloud.resource", Period: time.Hour},
+		{Name: "fresh", Type: api.RQLConfig, Query: "config from cloud.resource", Period: time.Hour},
+	}
+	collectRQLResults(&m, tenant, queries)
+	assert.Equal(t, map[string]*api.RQLResult{"fresh": {Name: "fresh", Count: 2}}, m.rqlResults,
+		"Failed query result is discarded and query which period didn't pass yet is not run")
+	assert.Contains(t, tenant.rqlLastRun, "q")
+}
+
+func TestDiffSCCSources(t *testing.T) {
+	added, removed := diffSCCSources(
+		map[string]string{"sources/1": "kept", "sources/2": "deleted", "sources/3": "deleted too"},
+		map[string]string{"sources/1": "kept", "sources/5": "new", "sources/4": "new too"})
+	assert.Equal(t, []string{"sources/4", "sources/5"}, added)
+	assert.Equal(t, []string{"sources/2", "sources/3"}, removed)
+	added, removed = diffSCCSources(nil, nil)
+	assert.Nil(t, added)
+	assert.Nil(t, removed)
+}
+
+func TestRefreshSCCSources(t *testing.T) {
+	sources := map[string]string{"sources/1": "kept"}
+	scc := &mockSCC{err: fmt.Errorf("mock error")}
+	p := &sccParent{parent: "1", sources: sources, sourcesUpdated: time.Now()}
+	refreshSCCSources(p, scc, time.Hour)
+	assert.Equal(t, sources, p.sources, "Sources are not refreshed before refresh period passes")
+
+	p.sourcesUpdated = time.Now().Add(-time.Hour * 2)
+	refreshSCCSources(p, scc, time.Hour)
+	assert.Equal(t, sources, p.sources, "Known sources are kept when refresh fails")
+	assert.WithinDuration(t, time.Now(), p.sourcesUpdated, time.Minute, "Failed refresh is not retried immediately")
+
+	scc.err = nil
+	scc.sources = map[string]string{"sources/2": "new"}
+	p.sourcesUpdated = time.Now().Add(-time.Hour * 2)
+	refreshSCCSources(p, scc, time.Hour)
+	assert.Equal(t, scc.sources, p.sources, "Sources are replaced by discovered ones")
+
+	p = &sccParent{parent: "1", sources: sources}
+	refreshSCCSources(p, scc, 0)
+	assert.Equal(t, time.Time{}, p.sourcesUpdated, "Refresh is disabled with zero period")
+}
+
+func TestLoadSCCParents(t *testing.T) {
+	var testDataset = []struct {
+		data    string
+		error   string
+		parents []*sccParent
+	}{
+		{data: "not_json",
+			error: "can't parse SCC parents file: invalid character 'o' in literal null (expecting 'u')"},
+		{data: `[{"name":"bad.name","parent":"organizations/1"}]`,
+			error: `bad SCC parent name "bad.name", it must be unique and contain only letters, digits, _ and -`},
+		{data: `[{"name":"acme","parent":"organizations/1"},{"name":"acme","parent":"organizations/2"}]`,
+			error: `bad SCC parent name "acme", it must be unique and contain only letters, digits, _ and -`},
+		{data: `[{"name":"acme","parent":"1"}]`,
+			error: `bad SCC parent "1" of "acme", it must be organizations/<id>, folders/<id> or projects/<id>`},
+		{data: `[{"name":"acme","parent":"organizations/1"},{"name":"dev","parent":"folders/2","sources_regex":"^SHA$"},
+{"name":"web","parent":"projects/web-prod"}]`,
+			parents: []*sccParent{
+				{name: "acme", parent: "organizations/1", sourcesRegex: "."},
+				{name: "dev", parent: "folders/2", sourcesRegex: "^SHA$"},
+				{name: "web", parent: "projects/web-prod", sourcesRegex: "."}}},
+	}
+	for i, x := range testDataset {
+		path := filepath.Join(t.TempDir(), "parents.json")
+		assert.NoError(t, os.WriteFile(path, []byte(x.data), 0o600))
+		parents, err := loadSCCParents(path, ".")
+		if x.error != "" {
+			assert.EqualError(t, err, x.error, "Test case %d error check failed", i)
+		} else {
+			assert.NoError(t, err, "Test case %d error check failed", i)
+		}
+		assert.Equal(t, x.parents, parents, "Test case %d parents check failed", i)
+	}
+}
+
+func TestCollectMetrics_SCC(t *testing.T) {
+	scc := &mockSCC{
+		delay:         map[string]time.Duration{"SHA": time.Minute},
+		errs:          map[string]error{"Forseti": fmt.Errorf("mock error")},
+		counts:        []api.SCCFindingsCount{{Source: "SHA", Category: "OPEN_FIREWALL", Severity: "high", Count: 2}},
+		owners:        &api.SCCOwnersFindings{Projects: map[string]*api.SCCSeverityCounts{"web": {High: 2}}},
+		lifecycle:     []api.SCCFindingsLifecycle{{Source: "SHA", Severity: "high", Created: 1}},
+		mute:          &api.SCCMuteInfo{MutedByCategory: map[string]int64{"OPEN_FIREWALL": 1}},
+		assets:        []api.SCCAssetCount{{ResourceType: "google.compute.Instance", Project: "web", Count: 1}},
+		notifications: []api.SCCNotificationHealth{{ID: "siem", ValidTopic: true, ValidFilter: true, Healthy: true}},
+	}
+	sources := map[string]string{"sources/1": "SHA", "sources/2": "Forseti"}
+	c := &collectors{scc: scc, sccParents: []*sccParent{{parent: "1", sources: sources}, {name: "acme", parent: "folders/2"}}}
+	m := metrics{}
+	collectMetrics(&m, c, "")
+	assert.Equal(t, metrics{scc: map[string]*sccMetrics{
+		"":     {sources: sources, sourcesDelay: scc.delay, sourcesErrors: scc.errs},
+		"acme": {sourcesDelay: scc.delay, sourcesErrors: scc.errs},
+	}}, m, "Only enabled SCC collectors are run for every parent")
+
+	c.sccFindings = true
+	c.sccProjectFilter = &api.SCCProjectFilter{}
+	c.sccLifecycleWindow = time.Hour
+	c.sccMute = true
+	c.sccAssets = true
+	c.sccNotifications = true
+	collectMetrics(&m, c, "")
+	assert.Equal(t, &sccMetrics{sources: sources, sourcesDelay: scc.delay, sourcesErrors: scc.errs,
+		findingsCounts: scc.counts, ownersFindings: scc.owners, lifecycle: scc.lifecycle,
+		muteInfo: scc.mute, assetCounts: scc.assets, notifications: scc.notifications}, m.scc[""])
+}
+
+func TestGenerateSCCGraphiteMetrics(t *testing.T) {
+	m := &sccMetrics{sources: map[string]string{"sources/1": "SHA"}, sourcesDelay: map[string]time.Duration{"SHA": time.Minute}}
+	o := opts{SCCSourcesMetricName: "scc_sources", SCCDelayPrefix: "scc_delay."}
+	assert.Equal(t, map[string]float64{
+		"scc_sources":           1,
+		"scc_delay.SHA.seconds": 60,
+		"scc_delay.SHA.error":   0,
+	}, generateSCCGraphiteMetrics(m, tenantPrefix(""), o), "Default parent metrics are not prefixed")
+	assert.Equal(t, map[string]float64{
+		"acme.scc_sources":           1,
+		"acme.scc_delay.SHA.seconds": 60,
+		"acme.scc_delay.SHA.error":   0,
+	}, generateSCCGraphiteMetrics(m, tenantPrefix("acme"), o), "Named parent metrics are prefixed with its name")
+}
+
+// mockSCC is sccCollector returning given data and error
+type mockSCC struct {
+	sources       map[string]string
+	delay         map[string]time.Duration
+	counts        []api.SCCFindingsCount
+	owners        *api.SCCOwnersFindings
+	lifecycle     []api.SCCFindingsLifecycle
+	mute          *api.SCCMuteInfo
+	assets        []api.SCCAssetCount
+	notifications []api.SCCNotificationHealth
+	errs          map[string]error
+	err           error
+}
+
+func (m *mockSCC) GetSourcesByName(_, _ string) (map[string]string, error) {
+	return m.sources, m.err
+}
+
+func (m *mockSCC) GetLatestEventTime(_ map[string]string) (map[string]time.Duration, map[string]error) {
+	return m.delay, m.errs
+}
+
+func (m *mockSCC) GetFindingsCounts(_ map[string]string) ([]api.SCCFindingsCount, map[string]error) {
+	return m.counts, m.errs
+}
+
+func (m *mockSCC) GetFindingsLifecycle(_ map[string]string, _ time.Duration) ([]api.SCCFindingsLifecycle, map[string]error) {
+	return m.lifecycle, m.errs
+}
+
+func (m *mockSCC) GetMuteInfo(_ string, _ map[string]string) (*api.SCCMuteInfo, map[string]error, error) {
+	return m.mute, m.errs, m.err
+}
+
+func (m *mockSCC) GetAssetCounts(_ string) ([]api.SCCAssetCount, error) {
+	return m.assets, m.err
+}
+
+func (m *mockSCC) GetNotificationsHealth(_ string, _ time.Duration) ([]api.SCCNotificationHealth, error) {
+	return m.notifications, m.err
+}
+
+func (m *mockSCC) GetOwnersFindings(_ map[string]string, _ *api.SCCProjectFilter) (*api.SCCOwnersFindings, map[string]error) {
+	return m.owners, m.errs
+}
+
+// badTenant returns Prisma tenant with given name, unreachable API and no retries
+func badTenant(name string) *prismaTenant {
+	p := api.NewPrisma("bad", "bad_pass", "bad_host")
+	p.SetRetryPolicy(0, 0)
+	return &prismaTenant{name: name, prisma: p, rqlLastRun: map[string]time.Time{}}
 }